@@ -0,0 +1,163 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/go-vela/vela-k6/merge"
+	"github.com/go-vela/vela-k6/models"
+	"github.com/go-vela/vela-k6/plugin"
+	"github.com/go-vela/vela-k6/report"
+)
+
+// newFlagSet returns the flag set of a subcommand, printing its usage
+// line and description before the flags.
+func newFlagSet(name, args, description string) *flag.FlagSet {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: vela-k6 %s [flags]%s\n\n%s\n\n", name, args, description)
+		flags.PrintDefaults()
+	}
+
+	return flags
+}
+
+// parsePluginFlags parses the plugin parameter flags of a subcommand, and
+// sets the parameters they override.
+func parsePluginFlags(name, description string, args []string) error {
+	flags := newFlagSet(name, "", description)
+	apply := plugin.RegisterFlags(flags)
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if flags.NArg() > 0 {
+		return fmt.Errorf("unexpected argument %q, parameters are set with flags such as --script-path", flags.Arg(0))
+	}
+
+	return apply()
+}
+
+// runRun runs the run subcommand, which runs the plugin like a Vela step
+// with the parameters set by flags or the environment.
+func runRun(args []string) error {
+	err := parsePluginFlags("run", "Runs the tests like the Vela step. Flags override the PARAMETER_* environment variables.", args)
+	if err != nil {
+		return err
+	}
+
+	return runPlugin()
+}
+
+// runValidate runs the validate subcommand, which checks the parameters
+// and the k6 binary and inspects the script, without running the setup
+// script or the tests.
+func runValidate(args []string) error {
+	err := parsePluginFlags("validate", "Checks the parameters and the k6 binary and inspects the script, without running the tests.", args)
+	if err != nil {
+		return err
+	}
+
+	p := plugin.New()
+	if err := p.ConfigFromEnv(); err != nil {
+		return err
+	}
+
+	if err := p.CheckK6(); err != nil {
+		return err
+	}
+
+	if err := p.InspectScript(); err != nil {
+		return err
+	}
+
+	log.Println("Configuration is valid.")
+
+	return nil
+}
+
+// runReport runs the report subcommand, which prints the summary of k6
+// results.
+func runReport(args []string) error {
+	flags := newFlagSet("report", " <file>...", "Prints the metrics and thresholds of k6 JSON outputs or summary exports, merged if several are given.")
+	format := flags.String("format", report.FormatText, "output format, one of: "+strings.Join(report.Formats, ", "))
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return fmt.Errorf("no file to report")
+	}
+
+	summary, err := readSummary(flags.Args()...)
+	if err != nil {
+		return err
+	}
+
+	return report.Write(os.Stdout, summary, *format)
+}
+
+// runCompare runs the compare subcommand, which fails if k6 results
+// regressed from a baseline.
+func runCompare(args []string) error {
+	flags := newFlagSet("compare", " <baseline> <current>", "Compares the avg and p(95) of trend metrics of k6 results against a baseline, and fails on regressions.")
+	tolerance := flags.Float64("tolerance", report.DefaultTolerance, "percentage by which a stat may exceed the baseline")
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if flags.NArg() != 2 {
+		flags.Usage()
+		return fmt.Errorf("expected a baseline and a current file, got %d files", flags.NArg())
+	}
+
+	baseline, err := readSummary(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	current, err := readSummary(flags.Arg(1))
+	if err != nil {
+		return err
+	}
+
+	regressions := report.Compare(baseline, current, *tolerance)
+	for _, regression := range regressions {
+		log.Printf("Regression: %s\n", regression)
+	}
+
+	if len(regressions) > 0 {
+		return fmt.Errorf("%d regressions over %.1f%%", len(regressions), *tolerance)
+	}
+
+	log.Printf("No regression over %.1f%%.\n", *tolerance)
+
+	return nil
+}
+
+// readSummary reads k6 JSON outputs or summary exports into a summary,
+// merged if several paths are given.
+func readSummary(paths ...string) (*models.Summary, error) {
+	aggregator := merge.New()
+
+	for _, path := range paths {
+		if err := aggregator.AddFile(path); err != nil {
+			return nil, fmt.Errorf("read %s: %w", path, err)
+		}
+	}
+
+	summary := aggregator.Summary(nil)
+	warnInexact(aggregator)
+
+	return summary, nil
+}
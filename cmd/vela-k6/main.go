@@ -1,45 +1,117 @@
 // SPDX-License-Identifier: Apache-2.0
 
 // Package main is the entry point for the Vela K6 plugin.
-// It captures the version information, configures the plugin from environment variables,
-// runs the setup script, and executes performance tests.
+// Without arguments, as in a Vela step, it logs the version information,
+// configures the plugin from environment variables, checks the k6
+// binary, inspects the script, runs the setup script, executes
+// performance tests, and sends notifications. Subcommands run the same
+// steps with flags instead of environment variables, or work with k6
+// results locally.
 package main
 
 import (
-	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
 	"log"
 	"os"
 
 	"github.com/go-vela/vela-k6/plugin"
-	"github.com/go-vela/vela-k6/version"
 )
 
+// usage documents the subcommands.
+const usage = `Usage: vela-k6 [command] [flags]
+
+Without a command, the plugin is configured from the PARAMETER_* environment
+variables set by Vela and runs the tests.
+
+Commands:
+  run       run the tests, with a flag for every plugin parameter
+  validate  check the parameters and the script without running the tests
+  report    print the summary of k6 results
+  compare   compare k6 results against a baseline
+  merge     merge k6 results into one summary export
+  version   print the version of the plugin and of k6
+
+Run 'vela-k6 <command> -h' for the flags of a command.
+`
+
 func main() {
-	// capture application version information
-	v := version.New()
+	if err := run(os.Args[1:]); err != nil {
+		log.Fatalf("FATAL: %s\n", err)
+	}
+}
 
-	// serialize the version information as pretty JSON
-	var bytes []byte
+// run runs the subcommand named by the first argument, or the plugin
+// configured from the environment when there is none.
+func run(args []string) error {
+	if len(args) == 0 {
+		return runPlugin()
+	}
 
 	var err error
-	if bytes, err = json.MarshalIndent(v, "", "  "); err != nil {
-		log.Fatal(err)
+
+	switch args[0] {
+	case "run":
+		err = runRun(args[1:])
+	case "validate":
+		err = runValidate(args[1:])
+	case "report":
+		err = runReport(args[1:])
+	case "compare":
+		err = runCompare(args[1:])
+	case "merge":
+		err = runMerge(args[1:])
+	case "version":
+		err = runVersion(args[1:])
+	case "help", "-h", "-help", "--help":
+		fmt.Fprint(os.Stdout, usage)
+	default:
+		fmt.Fprint(os.Stderr, usage)
+		return fmt.Errorf("unknown command %q", args[0])
+	}
+
+	if errors.Is(err, flag.ErrHelp) {
+		return nil
 	}
 
-	// output the version information to stdout
-	_, _ = fmt.Fprintf(os.Stdout, "%s\n", string(bytes))
+	return err
+}
 
+// runPlugin configures the plugin from the environment and runs the
+// tests, or prints the plan in dry run mode.
+func runPlugin() error {
 	p := plugin.New()
-	if err = p.ConfigFromEnv(); err != nil {
-		log.Fatalf("FATAL: %s\n", err)
+
+	err := p.ConfigFromEnv()
+	if err != nil || !p.Quiet() {
+		logVersion()
 	}
 
-	if err = p.RunSetupScript(); err != nil {
-		log.Fatalf("FATAL: %s\n", err)
+	if err != nil {
+		return err
 	}
 
-	if err = p.RunPerfTests(); err != nil {
-		log.Fatalf("FATAL: %s\n", err)
+	if p.DryRunEnabled() {
+		return p.DryRun()
+	}
+
+	err = p.CheckK6()
+	if err == nil {
+		err = p.InspectScript()
+	}
+
+	if err == nil {
+		err = p.RunSetupScript()
+	}
+
+	if err == nil {
+		err = p.RunPerfTests()
 	}
+
+	if notifyErr := p.Notify(err); notifyErr != nil {
+		log.Printf("WARNING: %s\n", notifyErr)
+	}
+
+	return err
 }
@@ -0,0 +1,71 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/go-vela/vela-k6/merge"
+)
+
+// runMerge runs the merge subcommand, which merges k6 JSON outputs and
+// summary exports into a single summary export.
+func runMerge(args []string) error {
+	flags := newFlagSet("merge", " <file>...", "Merges k6 JSON outputs (--out json=...) and summary exports (--summary-export) into one summary export.")
+	output := flags.String("output", "", "path to write the merged summary export to, instead of stdout")
+	trendStats := flags.String("summary-trend-stats", strings.Join(merge.DefaultTrendStats, ","), "comma-separated stats of trend metrics computed from JSON outputs")
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return errors.New("no file to merge")
+	}
+
+	aggregator := merge.New()
+
+	for _, path := range flags.Args() {
+		if err := aggregator.AddFile(path); err != nil {
+			return fmt.Errorf("read %s: %w", path, err)
+		}
+	}
+
+	summary := aggregator.Summary(strings.Split(*trendStats, ","))
+
+	warnInexact(aggregator)
+
+	for _, threshold := range summary.BreachedThresholds() {
+		log.Printf("Threshold breached: %s\n", threshold)
+	}
+
+	data, err := json.MarshalIndent(summary, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode summary: %w", err)
+	}
+
+	if *output == "" {
+		_, err = fmt.Fprintf(os.Stdout, "%s\n", data)
+		return err
+	}
+
+	return os.WriteFile(*output, data, 0600)
+}
+
+// warnInexact logs the stats the aggregator left out of its last summary
+// because they merge summary exports.
+func warnInexact(aggregator *merge.Aggregator) {
+	inexact := aggregator.Inexact()
+	for _, name := range slices.Sorted(maps.Keys(inexact)) {
+		log.Printf("WARNING: %s merges summary exports: %s can't be merged exactly, so they are left out and their thresholds fail; merge the k6 JSON outputs instead\n",
+			name, strings.Join(inexact[name], ", "))
+	}
+}
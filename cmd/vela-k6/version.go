@@ -0,0 +1,109 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	vela "github.com/go-vela/server/version"
+	"github.com/go-vela/vela-k6/models"
+	"github.com/go-vela/vela-k6/plugin"
+	"github.com/go-vela/vela-k6/version"
+)
+
+// Formats of the version subcommand.
+const (
+	versionFormatJSON = "json"
+	versionFormatText = "text"
+)
+
+// versionInfo is the version information of the plugin and of the k6
+// binary it runs.
+type versionInfo struct {
+	*vela.Version
+	K6 *models.K6Version `json:"k6,omitempty"`
+}
+
+// logVersion logs the version of the plugin as a single line of key=value
+// pairs.
+func logVersion() {
+	v := version.New()
+
+	log.Printf("vela-k6 version=%s commit=%s date=%s go=%s platform=%s/%s\n",
+		v.Canonical, v.Metadata.GitCommit, v.Metadata.BuildDate, v.Metadata.GoVersion,
+		v.Metadata.OperatingSystem, v.Metadata.Architecture)
+}
+
+// runVersion runs the version subcommand, which prints the version of the
+// plugin and of the k6 binary set in 'k6_binary_path', or on the PATH.
+func runVersion(args []string) error {
+	flags := newFlagSet("version", "", "Prints the version of the plugin, and the version and extensions of k6.")
+	format := flags.String("format", versionFormatJSON, "output format, one of: json, text")
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if *format != versionFormatJSON && *format != versionFormatText {
+		return fmt.Errorf("unknown format %q, must be one of: json, text", *format)
+	}
+
+	info := versionInfo{Version: version.New()}
+
+	// k6 is resolved like the run does, so the binary set in
+	// 'k6_binary_path' is reported
+	p := plugin.New()
+	if err := p.K6BinaryFromEnv(); err != nil {
+		return err
+	}
+
+	k6, err := p.K6Version()
+	if err != nil {
+		log.Printf("WARNING: get k6 version: %s\n", err)
+	}
+
+	info.K6 = k6
+
+	if *format == versionFormatText {
+		_, err = fmt.Fprint(os.Stdout, formatVersion(info))
+		return err
+	}
+
+	data, err := json.MarshalIndent(info, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode version: %w", err)
+	}
+
+	_, err = fmt.Fprintf(os.Stdout, "%s\n", data)
+
+	return err
+}
+
+// formatVersion renders the version information as text.
+func formatVersion(info versionInfo) string {
+	var b strings.Builder
+
+	meta := info.Metadata
+	fmt.Fprintf(&b, "vela-k6 %s\n", info.Canonical)
+	fmt.Fprintf(&b, "  commit:   %s\n", meta.GitCommit)
+	fmt.Fprintf(&b, "  built:    %s\n", meta.BuildDate)
+	fmt.Fprintf(&b, "  go:       %s (%s)\n", meta.GoVersion, meta.Compiler)
+	fmt.Fprintf(&b, "  platform: %s/%s\n", meta.OperatingSystem, meta.Architecture)
+
+	if info.K6 == nil {
+		b.WriteString("k6 unknown\n")
+		return b.String()
+	}
+
+	fmt.Fprintf(&b, "k6 %s\n", info.K6.Version)
+
+	for _, ext := range info.K6.Extensions {
+		fmt.Fprintf(&b, "  extension: %s %s, %s [%s]\n", ext.Module, ext.Version, ext.Name, ext.Type)
+	}
+
+	return b.String()
+}
@@ -0,0 +1,209 @@
+// SPDX-License-Identifier: Apache-2.0
+
+// Package k6api provides a client for the REST API k6 serves while a test
+// runs, which reports the status and metrics of the test and can pause,
+// resume, scale or stop it.
+package k6api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// DefaultAddress is the address k6 serves its REST API on by default.
+const DefaultAddress = "localhost:6565"
+
+// defaultTimeout is the timeout of each request when the caller's
+// context has no deadline.
+const defaultTimeout = 5 * time.Second
+
+// Execution statuses reported by k6 in Status.Status.
+var executionStatuses = []string{
+	"created",
+	"init-vus",
+	"init-executors",
+	"init-done",
+	"paused-before-run",
+	"started",
+	"setup",
+	"running",
+	"teardown",
+	"ended",
+	"interrupted",
+}
+
+// Client calls the REST API of a single k6 instance.
+type Client struct {
+	baseURL string
+	http    *http.Client
+}
+
+// New returns a client for the k6 instance serving its REST API on
+// address, such as "localhost:6565".
+func New(address string) *Client {
+	return &Client{baseURL: "http://" + address, http: http.DefaultClient}
+}
+
+// Status is the status of the running test.
+type Status struct {
+	// Status is the execution status of the test, see State.
+	Status  int  `json:"status"`
+	Paused  bool `json:"paused"`
+	VUs     int  `json:"vus"`
+	VUsMax  int  `json:"vus-max"`
+	Stopped bool `json:"stopped"`
+	Running bool `json:"running"`
+	Tainted bool `json:"tainted"`
+}
+
+// State returns the name of the execution status, such as "running".
+func (s Status) State() string {
+	if s.Status < 0 || s.Status >= len(executionStatuses) {
+		return fmt.Sprintf("unknown (%d)", s.Status)
+	}
+
+	return executionStatuses[s.Status]
+}
+
+// Metric is the current aggregated sample of a k6 metric.
+type Metric struct {
+	Name     string `json:"-"`
+	Type     string `json:"type"`
+	Contains string `json:"contains"`
+	Tainted  bool   `json:"tainted"`
+	// Sample maps a stat name (e.g. "avg", "p(95)", "count", "rate") to
+	// its current value.
+	Sample map[string]float64 `json:"sample"`
+}
+
+// statusUpdate is the subset of Status that can be changed with a PATCH
+// request.
+type statusUpdate struct {
+	Paused  *bool `json:"paused,omitempty"`
+	VUs     *int  `json:"vus,omitempty"`
+	VUsMax  *int  `json:"vus-max,omitempty"`
+	Stopped *bool `json:"stopped,omitempty"`
+}
+
+// resource is the JSON:API envelope of a single k6 API object.
+type resource[T any] struct {
+	Type       string `json:"type"`
+	ID         string `json:"id"`
+	Attributes T      `json:"attributes"`
+}
+
+// Status returns the current status of the test.
+func (c *Client) Status(ctx context.Context) (Status, error) {
+	var body struct {
+		Data resource[Status] `json:"data"`
+	}
+
+	if err := c.do(ctx, http.MethodGet, "/v1/status", nil, &body); err != nil {
+		return Status{}, err
+	}
+
+	return body.Data.Attributes, nil
+}
+
+// Metrics returns the current value of every metric of the test.
+func (c *Client) Metrics(ctx context.Context) ([]Metric, error) {
+	var body struct {
+		Data []resource[Metric] `json:"data"`
+	}
+
+	if err := c.do(ctx, http.MethodGet, "/v1/metrics", nil, &body); err != nil {
+		return nil, err
+	}
+
+	metrics := make([]Metric, len(body.Data))
+	for i, data := range body.Data {
+		metrics[i] = data.Attributes
+		metrics[i].Name = data.ID
+	}
+
+	return metrics, nil
+}
+
+// Pause pauses the test.
+func (c *Client) Pause(ctx context.Context) error {
+	paused := true
+	return c.update(ctx, statusUpdate{Paused: &paused})
+}
+
+// Resume resumes the paused test.
+func (c *Client) Resume(ctx context.Context) error {
+	paused := false
+	return c.update(ctx, statusUpdate{Paused: &paused})
+}
+
+// ScaleVUs sets the number of active VUs. It is only supported by the
+// externally-controlled executor.
+func (c *Client) ScaleVUs(ctx context.Context, vus int) error {
+	return c.update(ctx, statusUpdate{VUs: &vus})
+}
+
+// Stop stops the test gracefully. k6 then runs the teardown and writes
+// the end-of-test summary.
+func (c *Client) Stop(ctx context.Context) error {
+	stopped := true
+	return c.update(ctx, statusUpdate{Stopped: &stopped})
+}
+
+// update changes the status of the test.
+func (c *Client) update(ctx context.Context, update statusUpdate) error {
+	body := struct {
+		Data resource[statusUpdate] `json:"data"`
+	}{Data: resource[statusUpdate]{Type: "status", ID: "default", Attributes: update}}
+
+	return c.do(ctx, http.MethodPatch, "/v1/status", body, nil)
+}
+
+// do sends a request with the JSON encoding of in, if any, and decodes
+// the response into out, if any.
+func (c *Client) do(ctx context.Context, method, path string, in, out any) error {
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+
+		ctx, cancel = context.WithTimeout(ctx, defaultTimeout)
+		defer cancel()
+	}
+
+	var body bytes.Buffer
+
+	if in != nil {
+		if err := json.NewEncoder(&body).Encode(in); err != nil {
+			return fmt.Errorf("encode request: %w", err)
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, &body)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("%s %s: unexpected response status %s", method, path, resp.Status)
+	}
+
+	if out == nil {
+		return nil
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("decode %s response: %w", path, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,99 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package k6api
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// request is a request received by the test server.
+type request struct {
+	Method string
+	Path   string
+	Body   string
+}
+
+// newTestClient returns a client for a server that records requests and
+// responds with the given status and body.
+func newTestClient(t *testing.T, status int, response string) (*Client, *[]request) {
+	t.Helper()
+
+	var requests []request
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		requests = append(requests, request{Method: r.Method, Path: r.URL.Path, Body: string(body)})
+
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(response))
+	}))
+	t.Cleanup(srv.Close)
+
+	return New(strings.TrimPrefix(srv.URL, "http://")), &requests
+}
+
+func TestStatus(t *testing.T) {
+	c, requests := newTestClient(t, http.StatusOK, `{"data": {"type": "status", "id": "default", "attributes": {
+		"status": 7, "paused": false, "vus": 20, "vus-max": 50, "stopped": false, "running": true, "tainted": true
+	}}}`)
+
+	status, err := c.Status(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, Status{Status: 7, VUs: 20, VUsMax: 50, Running: true, Tainted: true}, status)
+	assert.Equal(t, "running", status.State())
+	assert.Equal(t, []request{{Method: http.MethodGet, Path: "/v1/status"}}, *requests)
+
+	assert.Equal(t, "unknown (42)", Status{Status: 42}.State())
+}
+
+func TestMetrics(t *testing.T) {
+	c, _ := newTestClient(t, http.StatusOK, `{"data": [
+		{"type": "metrics", "id": "http_reqs", "attributes": {"type": "counter", "contains": "default", "tainted": null, "sample": {"count": 120, "rate": 12}}},
+		{"type": "metrics", "id": "http_req_duration", "attributes": {"type": "trend", "contains": "time", "tainted": true, "sample": {"avg": 101.5, "p(95)": 180}}}
+	]}`)
+
+	metrics, err := c.Metrics(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []Metric{
+		{Name: "http_reqs", Type: "counter", Contains: "default", Sample: map[string]float64{"count": 120, "rate": 12}},
+		{Name: "http_req_duration", Type: "trend", Contains: "time", Tainted: true, Sample: map[string]float64{"avg": 101.5, "p(95)": 180}},
+	}, metrics)
+}
+
+func TestUpdate(t *testing.T) {
+	c, requests := newTestClient(t, http.StatusOK, `{}`)
+	ctx := context.Background()
+
+	require.NoError(t, c.Pause(ctx))
+	require.NoError(t, c.Resume(ctx))
+	require.NoError(t, c.ScaleVUs(ctx, 10))
+	require.NoError(t, c.Stop(ctx))
+
+	require.Len(t, *requests, 4)
+
+	for i, expected := range []string{`{"paused":true}`, `{"paused":false}`, `{"vus":10}`, `{"stopped":true}`} {
+		assert.Equal(t, http.MethodPatch, (*requests)[i].Method)
+		assert.Equal(t, "/v1/status", (*requests)[i].Path)
+		assert.JSONEq(t, `{"data": {"type": "status", "id": "default", "attributes": `+expected+`}}`, (*requests)[i].Body)
+	}
+}
+
+func TestErrors(t *testing.T) {
+	c, _ := newTestClient(t, http.StatusBadRequest, `{"errors": []}`)
+	assert.EqualError(t, c.Stop(context.Background()), "PATCH /v1/status: unexpected response status 400 Bad Request")
+
+	c, _ = newTestClient(t, http.StatusOK, `not json`)
+	_, err := c.Status(context.Background())
+	assert.ErrorContains(t, err, "decode /v1/status response")
+
+	_, err = New("127.0.0.1:1").Metrics(context.Background())
+	assert.Error(t, err)
+}
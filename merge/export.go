@@ -0,0 +1,197 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package merge
+
+import (
+	"maps"
+	"math"
+	"slices"
+	"strings"
+
+	"github.com/go-vela/vela-k6/models"
+)
+
+// part is the summary of a metric from a single source: the samples of
+// the JSON outputs, or a summary export.
+type part struct {
+	kind       string
+	values     map[string]float64
+	thresholds map[string]bool
+	// samples is the number of samples of a trend, or 0 if unknown.
+	samples float64
+	// seconds is the duration a counter was measured over, or 0 if unknown.
+	seconds float64
+}
+
+// exportPart returns the part of a metric of a summary export. Exports
+// don't name the metric type, which is told apart by its stats.
+func exportPart(metric models.Metric) part {
+	p := part{kind: typeTrend, values: metric.Values, thresholds: metric.Thresholds}
+
+	_, hasAvg := metric.Values["avg"]
+	_, hasRate := metric.Values["rate"]
+	_, hasValue := metric.Values["value"]
+	_, hasPasses := metric.Values["passes"]
+
+	switch {
+	case hasPasses:
+		p.kind = typeRate
+	case hasRate && !hasAvg:
+		p.kind = typeCounter
+
+		if rate := metric.Values["rate"]; rate > 0 {
+			p.seconds = metric.Values["count"] / rate
+		}
+	case hasValue && !hasAvg:
+		p.kind = typeGauge
+	default:
+		p.samples = metric.Values["count"]
+	}
+
+	return p
+}
+
+// mergeParts merges the parts of a metric, and re-evaluates its
+// thresholds on the merged stats. inexact are the stats of trends that
+// can't be merged exactly without every sample, such as percentiles of
+// summary exports, or averages without a count: they are left out, and
+// the thresholds on them, like those on any stat that can't be merged,
+// fail rather than pass on an approximation.
+func mergeParts(parts []part) (metric models.Metric, inexact []string) {
+	if len(parts) == 1 {
+		return models.Metric{Values: parts[0].values, Thresholds: parts[0].thresholds}, nil
+	}
+
+	merged := map[string]float64{}
+	kind := parts[0].kind
+
+	switch kind {
+	case typeCounter:
+		count, seconds, rate := 0.0, 0.0, 0.0
+
+		for _, p := range parts {
+			count += p.values["count"]
+			rate += p.values["rate"]
+			seconds = math.Max(seconds, p.seconds)
+		}
+
+		// the parts are assumed to run at the same time, like shards: the
+		// rate is the total count over the longest part, or the sum of
+		// the rates when the durations are unknown
+		if seconds > 0 {
+			rate = count / seconds
+		}
+
+		merged["count"], merged["rate"] = count, rate
+	case typeRate:
+		passes, fails := 0.0, 0.0
+
+		for _, p := range parts {
+			passes += p.values["passes"]
+			fails += p.values["fails"]
+		}
+
+		merged["passes"], merged["fails"] = passes, fails
+		if passes+fails > 0 {
+			merged["value"] = passes / (passes + fails)
+		}
+	case typeGauge:
+		merged["min"], merged["max"] = math.Inf(1), math.Inf(-1)
+
+		for _, p := range parts {
+			merged["min"] = math.Min(merged["min"], p.values["min"])
+			merged["max"] = math.Max(merged["max"], p.values["max"])
+			merged["value"] = p.values["value"]
+		}
+	default:
+		merged, inexact = mergeTrend(parts)
+	}
+
+	metric = models.Metric{Values: merged}
+
+	expressions := map[string]bool{}
+	for _, p := range parts {
+		for expression, failed := range p.thresholds {
+			expressions[expression] = expressions[expression] || failed
+		}
+	}
+
+	for _, expression := range slices.Sorted(maps.Keys(expressions)) {
+		if metric.Thresholds == nil {
+			metric.Thresholds = map[string]bool{}
+		}
+
+		passed, err := evaluateThreshold(expression, func(stat string) (float64, bool) {
+			if stat == "rate" && kind == typeRate {
+				stat = "value"
+			}
+
+			value, ok := merged[stat]
+
+			return value, ok
+		})
+		metric.Thresholds[expression] = err != nil || !passed
+	}
+
+	return metric, inexact
+}
+
+// mergeTrend merges the stats of trend parts that every part has, and
+// returns those that can't be merged exactly as inexact.
+func mergeTrend(parts []part) (merged map[string]float64, inexact []string) {
+	merged = map[string]float64{}
+
+	samples := 0.0
+	weighted := true
+
+	for _, p := range parts {
+		samples += p.samples
+		weighted = weighted && p.samples > 0
+	}
+
+	for stat := range parts[0].values {
+		values := make([]float64, 0, len(parts))
+
+		for _, p := range parts {
+			if value, ok := p.values[stat]; ok {
+				values = append(values, value)
+			}
+		}
+
+		if len(values) < len(parts) {
+			continue
+		}
+
+		switch {
+		case stat == "count":
+			merged[stat] = sum(values)
+		case stat == "min":
+			merged[stat] = slices.Min(values)
+		case stat == "avg" && weighted:
+			total := 0.0
+			for i, p := range parts {
+				total += values[i] * p.samples
+			}
+
+			merged[stat] = total / samples
+		case stat == "max":
+			merged[stat] = slices.Max(values)
+		case stat == "avg" || stat == "med" || strings.HasPrefix(stat, "p("):
+			inexact = append(inexact, stat)
+		}
+	}
+
+	slices.Sort(inexact)
+
+	return merged, inexact
+}
+
+// sum returns the sum of values.
+func sum(values []float64) float64 {
+	total := 0.0
+	for _, value := range values {
+		total += value
+	}
+
+	return total
+}
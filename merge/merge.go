@@ -0,0 +1,420 @@
+// SPDX-License-Identifier: Apache-2.0
+
+// Package merge combines the results of several k6 runs, such as the
+// instances of a distributed run, retries or separate scripts, into a
+// single summary. The stats of raw k6 JSON outputs are computed from every
+// sample, aggregated in bounded memory: percentiles are estimated within 1%
+// rather than averaged across runs.
+// Summary exports only hold aggregates: their counts and rates are merged
+// exactly, while their percentiles can't be and are left out. The
+// thresholds are re-evaluated on the merged data.
+package merge
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/go-vela/vela-k6/models"
+	"github.com/go-vela/vela-k6/stats"
+)
+
+// DefaultTrendStats are the trend stats k6 includes in its summary by
+// default.
+var DefaultTrendStats = []string{"avg", "min", "med", "max", "p(90)", "p(95)"}
+
+// maxLineSize is the longest line accepted in a k6 JSON output.
+const maxLineSize = 1024 * 1024
+
+// Types of k6 metrics.
+const (
+	typeCounter = "counter"
+	typeGauge   = "gauge"
+	typeRate    = "rate"
+	typeTrend   = "trend"
+)
+
+// metricStats are the stats of each metric type in the k6 summary export.
+// Trend metrics have the configured trend stats.
+var metricStats = map[string][]string{
+	typeCounter: {"count", "rate"},
+	typeGauge:   {"value", "min", "max"},
+	typeRate:    {"passes", "fails", "value"},
+}
+
+// series aggregates the samples of a metric, or of a submetric selecting
+// the samples of its parent metric with given tags.
+type series struct {
+	kind       string
+	tags       map[string]string
+	thresholds []string
+	samples    *stats.Histogram
+	last       time.Time
+	lastValue  float64
+}
+
+// newSeries returns an empty series of a metric with tags, if any.
+func newSeries(tags map[string]string) *series {
+	return &series{tags: tags, samples: stats.NewHistogram()}
+}
+
+// Aggregator accumulates the samples of k6 JSON outputs and the metrics
+// of summary exports.
+type Aggregator struct {
+	metrics    map[string]*series
+	submetrics map[string]map[string]*series
+	first      time.Time
+	last       time.Time
+	exports    []*models.Summary
+	inexact    map[string][]string
+}
+
+// New returns an empty aggregator.
+func New() *Aggregator {
+	return &Aggregator{metrics: map[string]*series{}, submetrics: map[string]map[string]*series{}}
+}
+
+// AddThresholds registers thresholds to evaluate, in addition to the
+// thresholds found in the outputs, as a map of metric to expressions.
+// Metrics may select a submetric with a tag filter, such as
+// "http_req_duration{status:200}". Thresholds must be added before the
+// outputs so submetrics receive every sample.
+func (a *Aggregator) AddThresholds(thresholds map[string][]string) error {
+	for name, expressions := range thresholds {
+		s, err := a.series(name)
+		if err != nil {
+			return err
+		}
+
+		for _, expression := range expressions {
+			if !slices.Contains(s.thresholds, expression) {
+				s.thresholds = append(s.thresholds, expression)
+			}
+		}
+	}
+
+	return nil
+}
+
+// series returns the series of a metric or submetric name, creating it
+// if needed.
+func (a *Aggregator) series(name string) (*series, error) {
+	parent, filter, isSubmetric := strings.Cut(name, "{")
+	if !isSubmetric {
+		if _, ok := a.metrics[name]; !ok {
+			a.metrics[name] = newSeries(nil)
+		}
+
+		return a.metrics[name], nil
+	}
+
+	if !strings.HasSuffix(filter, "}") {
+		return nil, fmt.Errorf("%q is not a metric name", name)
+	}
+
+	if s, ok := a.submetrics[parent][name]; ok {
+		return s, nil
+	}
+
+	tags := map[string]string{}
+
+	for _, pair := range strings.Split(strings.TrimSuffix(filter, "}"), ",") {
+		key, value, ok := strings.Cut(pair, ":")
+		if !ok {
+			return nil, fmt.Errorf("%q: tag filter %q must be key:value", name, pair)
+		}
+
+		tags[strings.TrimSpace(key)] = strings.Trim(strings.TrimSpace(value), `"'`)
+	}
+
+	if a.submetrics[parent] == nil {
+		a.submetrics[parent] = map[string]*series{}
+	}
+
+	s := newSeries(tags)
+	if metric, ok := a.metrics[parent]; ok {
+		s.kind = metric.kind
+	}
+
+	a.submetrics[parent][name] = s
+
+	return s, nil
+}
+
+// outputLine is a line of the k6 JSON output, either a metric definition
+// or a sample.
+type outputLine struct {
+	Type   string `json:"type"`
+	Metric string `json:"metric"`
+	Data   struct {
+		// metric definitions
+		Type       string   `json:"type"`
+		Thresholds []string `json:"thresholds"`
+		// samples
+		Time  time.Time         `json:"time"`
+		Value float64           `json:"value"`
+		Tags  map[string]string `json:"tags"`
+	} `json:"data"`
+}
+
+// AddFile reads the k6 JSON output or the summary export at path.
+func (a *Aggregator) AddFile(path string) error {
+	f, err := os.Open(path) //nolint:gosec // path is validated by the caller
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	// a summary export is a single object with the metrics, while every
+	// line of a JSON output has a type
+	var probe struct {
+		Type    string                   `json:"type"`
+		Metrics map[string]models.Metric `json:"metrics"`
+	}
+
+	if err := json.NewDecoder(f).Decode(&probe); err == nil && probe.Type == "" && probe.Metrics != nil {
+		a.AddSummary(&models.Summary{Metrics: probe.Metrics})
+		return nil
+	}
+
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	if err := a.AddOutput(f); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+
+	return nil
+}
+
+// AddSummary adds the metrics of a summary export.
+func (a *Aggregator) AddSummary(summary *models.Summary) {
+	a.exports = append(a.exports, summary)
+}
+
+// AddOutput reads a k6 JSON output.
+func (a *Aggregator) AddOutput(r io.Reader) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxLineSize)
+
+	for n := 1; scanner.Scan(); n++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+
+		var line outputLine
+		if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+			return fmt.Errorf("line %d: %w", n, err)
+		}
+
+		switch line.Type {
+		case "Metric":
+			s, err := a.series(line.Metric)
+			if err != nil {
+				return fmt.Errorf("line %d: %w", n, err)
+			}
+
+			s.kind = line.Data.Type
+
+			for _, expression := range line.Data.Thresholds {
+				if !slices.Contains(s.thresholds, expression) {
+					s.thresholds = append(s.thresholds, expression)
+				}
+			}
+
+			for _, sub := range a.submetrics[line.Metric] {
+				sub.kind = line.Data.Type
+			}
+		case "Point":
+			a.addPoint(line.Metric, line.Data.Time, line.Data.Value, line.Data.Tags)
+		}
+	}
+
+	return scanner.Err()
+}
+
+// addPoint records a sample in its metric and in the matching submetrics.
+func (a *Aggregator) addPoint(metric string, at time.Time, value float64, tags map[string]string) {
+	s, _ := a.series(metric)
+	s.add(at, value)
+
+	for _, sub := range a.submetrics[metric] {
+		if matchesTags(sub.tags, tags) {
+			sub.add(at, value)
+		}
+	}
+
+	if a.first.IsZero() || at.Before(a.first) {
+		a.first = at
+	}
+
+	if at.After(a.last) {
+		a.last = at
+	}
+}
+
+// add records a sample in the series.
+func (s *series) add(at time.Time, value float64) {
+	s.samples.Add(value)
+
+	if !at.Before(s.last) {
+		s.last = at
+		s.lastValue = value
+	}
+}
+
+// matchesTags returns true if tags contain every tag of the filter.
+func matchesTags(filter, tags map[string]string) bool {
+	for key, value := range filter {
+		if tags[key] != value {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Summary returns the merged summary, with the given trend stats for trend
+// metrics and the result of every threshold. Metrics without samples are
+// left out.
+func (a *Aggregator) Summary(trendStats []string) *models.Summary {
+	if len(trendStats) == 0 {
+		trendStats = DefaultTrendStats
+	}
+
+	parts := map[string][]part{}
+	duration := a.last.Sub(a.first)
+
+	add := func(name string, s *series) {
+		if s.samples.Count == 0 {
+			return
+		}
+
+		p := part{
+			kind:       s.kind,
+			values:     s.stats(trendStats, duration),
+			thresholds: map[string]bool{},
+			samples:    float64(s.samples.Count),
+			seconds:    duration.Seconds(),
+		}
+
+		for _, expression := range s.thresholds {
+			passed, err := evaluateThreshold(expression, func(stat string) (float64, bool) { return s.stat(stat, duration) })
+			p.thresholds[expression] = err != nil || !passed
+		}
+
+		parts[name] = append(parts[name], p)
+	}
+
+	for name, s := range a.metrics {
+		add(name, s)
+	}
+
+	for _, subs := range a.submetrics {
+		for name, s := range subs {
+			add(name, s)
+		}
+	}
+
+	for _, export := range a.exports {
+		for name, metric := range export.Metrics {
+			parts[name] = append(parts[name], exportPart(metric))
+		}
+	}
+
+	summary := &models.Summary{Metrics: make(map[string]models.Metric, len(parts))}
+	a.inexact = map[string][]string{}
+
+	for _, name := range slices.Sorted(maps.Keys(parts)) {
+		metric, inexact := mergeParts(parts[name])
+		summary.Metrics[name] = metric
+
+		if len(inexact) > 0 {
+			a.inexact[name] = inexact
+		}
+	}
+
+	return summary
+}
+
+// Inexact returns the stats left out of the metrics of the last summary,
+// by metric, because they merge summary exports and can't be merged
+// exactly. The thresholds on these stats fail.
+func (a *Aggregator) Inexact() map[string][]string {
+	return a.inexact
+}
+
+// stats returns the summary stats of the series for its metric type, as
+// named in the k6 summary export.
+func (s *series) stats(trendStats []string, duration time.Duration) map[string]float64 {
+	values := map[string]float64{}
+
+	names, ok := metricStats[s.kind]
+	if !ok {
+		names = trendStats
+	}
+
+	for _, name := range names {
+		if value, ok := s.stat(name, duration); ok {
+			values[name] = value
+		}
+	}
+
+	return values
+}
+
+// stat computes a single stat of the series, or returns false if its
+// metric type has no such stat. duration is the length of the run, used
+// for the rate of counters.
+func (s *series) stat(name string, duration time.Duration) (float64, bool) {
+	if stats, ok := metricStats[s.kind]; ok && !slices.Contains(stats, name) && (s.kind != typeRate || name != "rate") {
+		return 0, false
+	}
+
+	switch {
+	case name == "count" && s.kind == typeCounter:
+		return s.samples.Sum, true
+	case name == "rate" && s.kind == typeCounter:
+		if duration <= 0 {
+			return 0, true
+		}
+
+		return s.samples.Sum / duration.Seconds(), true
+	case s.kind == typeRate:
+		passes := float64(s.samples.NonZero)
+
+		switch name {
+		case "passes":
+			return passes, true
+		case "fails":
+			return float64(s.samples.Count) - passes, true
+		default:
+			return passes / float64(s.samples.Count), true
+		}
+	case name == "value":
+		return s.lastValue, true
+	case name == "count":
+		return float64(s.samples.Count), true
+	case name == "avg":
+		return s.samples.Avg(), true
+	case name == "min":
+		return s.samples.Min, true
+	case name == "max":
+		return s.samples.Max, true
+	case name == "med":
+		return s.samples.Percentile(50), true
+	}
+
+	if p, ok := stats.ParsePercentile(name); ok {
+		return s.samples.Percentile(p), true
+	}
+
+	return 0, false
+}
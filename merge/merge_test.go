@@ -0,0 +1,171 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package merge
+
+import (
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// instanceOutputs are the k6 JSON outputs of two instances of a run.
+var instanceOutputs = []string{
+	`{"type":"Metric","data":{"name":"http_req_duration","type":"trend","contains":"time","thresholds":["p(95)<300"],"submetrics":null},"metric":"http_req_duration"}
+{"type":"Metric","data":{"name":"http_reqs","type":"counter","contains":"default","thresholds":[],"submetrics":null},"metric":"http_reqs"}
+{"type":"Metric","data":{"name":"http_req_failed","type":"rate","contains":"default","thresholds":["rate<0.3"],"submetrics":null},"metric":"http_req_failed"}
+{"type":"Metric","data":{"name":"vus","type":"gauge","contains":"default","thresholds":[],"submetrics":null},"metric":"vus"}
+{"type":"Point","data":{"time":"2024-01-01T00:00:00Z","value":100,"tags":{"status":"200"}},"metric":"http_req_duration"}
+{"type":"Point","data":{"time":"2024-01-01T00:00:00Z","value":1,"tags":{"status":"200"}},"metric":"http_reqs"}
+{"type":"Point","data":{"time":"2024-01-01T00:00:00Z","value":0,"tags":{"status":"200"}},"metric":"http_req_failed"}
+{"type":"Point","data":{"time":"2024-01-01T00:00:00Z","value":5,"tags":{}},"metric":"vus"}
+{"type":"Point","data":{"time":"2024-01-01T00:00:05Z","value":200,"tags":{"status":"200"}},"metric":"http_req_duration"}
+{"type":"Point","data":{"time":"2024-01-01T00:00:05Z","value":1,"tags":{"status":"200"}},"metric":"http_reqs"}
+{"type":"Point","data":{"time":"2024-01-01T00:00:05Z","value":0,"tags":{"status":"200"}},"metric":"http_req_failed"}
+`,
+	`{"type":"Metric","data":{"name":"http_req_duration","type":"trend","contains":"time","thresholds":["p(95)<300"],"submetrics":null},"metric":"http_req_duration"}
+{"type":"Metric","data":{"name":"http_reqs","type":"counter","contains":"default","thresholds":[],"submetrics":null},"metric":"http_reqs"}
+{"type":"Metric","data":{"name":"http_req_failed","type":"rate","contains":"default","thresholds":["rate<0.3"],"submetrics":null},"metric":"http_req_failed"}
+{"type":"Metric","data":{"name":"vus","type":"gauge","contains":"default","thresholds":[],"submetrics":null},"metric":"vus"}
+{"type":"Point","data":{"time":"2024-01-01T00:00:02Z","value":300,"tags":{"status":"500"}},"metric":"http_req_duration"}
+{"type":"Point","data":{"time":"2024-01-01T00:00:02Z","value":1,"tags":{"status":"500"}},"metric":"http_reqs"}
+{"type":"Point","data":{"time":"2024-01-01T00:00:02Z","value":1,"tags":{"status":"500"}},"metric":"http_req_failed"}
+{"type":"Point","data":{"time":"2024-01-01T00:00:08Z","value":400,"tags":{"status":"200"}},"metric":"http_req_duration"}
+{"type":"Point","data":{"time":"2024-01-01T00:00:08Z","value":1,"tags":{"status":"200"}},"metric":"http_reqs"}
+{"type":"Point","data":{"time":"2024-01-01T00:00:08Z","value":0,"tags":{"status":"200"}},"metric":"http_req_failed"}
+{"type":"Point","data":{"time":"2024-01-01T00:00:10Z","value":3,"tags":{}},"metric":"vus"}
+`,
+}
+
+// assertTrend asserts that the stats of a trend are those expected, with
+// the error of the percentiles estimated by the histogram.
+func assertTrend(t *testing.T, expected, actual map[string]float64) {
+	t.Helper()
+
+	assert.ElementsMatch(t, slices.Collect(maps.Keys(expected)), slices.Collect(maps.Keys(actual)))
+
+	for stat, value := range expected {
+		assert.InEpsilon(t, value, actual[stat], 0.01, stat)
+	}
+}
+
+func TestSummary(t *testing.T) {
+	a := New()
+	require.NoError(t, a.AddThresholds(map[string][]string{
+		"http_req_duration{status:200}": {"max<500"},
+		"http_req_duration":             {"p(95)<300", "avg<1000"},
+	}))
+
+	for _, output := range instanceOutputs {
+		require.NoError(t, a.AddOutput(strings.NewReader(output)))
+	}
+
+	summary := a.Summary([]string{"avg", "med", "p(95)", "count"})
+
+	assertTrend(t, map[string]float64{"avg": 250, "med": 250, "p(95)": 385, "count": 4}, summary.Metrics["http_req_duration"].Values)
+	assert.Equal(t, map[string]bool{"p(95)<300": true, "avg<1000": false}, summary.Metrics["http_req_duration"].Thresholds)
+
+	assertTrend(t, map[string]float64{"avg": 700.0 / 3, "med": 200, "p(95)": 380, "count": 3}, summary.Metrics["http_req_duration{status:200}"].Values)
+	assert.Equal(t, map[string]bool{"max<500": false}, summary.Metrics["http_req_duration{status:200}"].Thresholds)
+
+	assert.Equal(t, map[string]float64{"count": 4, "rate": 0.4}, summary.Metrics["http_reqs"].Values)
+	assert.Equal(t, map[string]float64{"passes": 1, "fails": 3, "value": 0.25}, summary.Metrics["http_req_failed"].Values)
+	assert.Equal(t, map[string]bool{"rate<0.3": false}, summary.Metrics["http_req_failed"].Thresholds)
+	assert.Equal(t, map[string]float64{"value": 3, "min": 3, "max": 5}, summary.Metrics["vus"].Values)
+
+	assert.Equal(t, []string{"http_req_duration: p(95)<300"}, summary.BreachedThresholds())
+}
+
+func TestSummaryDefaults(t *testing.T) {
+	a := New()
+	require.NoError(t, a.AddOutput(strings.NewReader(instanceOutputs[0])))
+
+	summary := a.Summary(nil)
+	assertTrend(t, map[string]float64{"avg": 150, "min": 100, "med": 150, "max": 200, "p(90)": 190, "p(95)": 195},
+		summary.Metrics["http_req_duration"].Values)
+	assert.NotContains(t, a.Summary(nil).Metrics, "http_req_duration{status:200}")
+}
+
+func TestInvalidThresholds(t *testing.T) {
+	a := New()
+	assert.EqualError(t, a.AddThresholds(map[string][]string{"http_req_duration{status}": {"p(95)<300"}}),
+		`"http_req_duration{status}": tag filter "status" must be key:value`)
+
+	require.NoError(t, a.AddThresholds(map[string][]string{"http_req_duration": {"p(95) less than 300"}, "vus": {"avg<10"}}))
+	require.NoError(t, a.AddOutput(strings.NewReader(instanceOutputs[0])))
+
+	// invalid expressions and stats the metric type doesn't have fail
+	summary := a.Summary(nil)
+	assert.Equal(t, map[string]bool{"p(95)<300": false, "p(95) less than 300": true}, summary.Metrics["http_req_duration"].Thresholds)
+	assert.Equal(t, map[string]bool{"avg<10": true}, summary.Metrics["vus"].Thresholds)
+}
+
+func TestAddFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "out.json")
+	require.NoError(t, os.WriteFile(path, []byte(instanceOutputs[1]+"not json\n"), 0600))
+
+	a := New()
+	assert.EqualError(t, a.AddFile(path), path+": line 12: invalid character 'o' in literal null (expecting 'u')")
+	assert.Error(t, a.AddFile(filepath.Join(dir, "missing.json")))
+}
+
+// summaryExport is a k6 summary export of a run with the same metrics as
+// instanceOutputs.
+const summaryExport = `{
+  "root_group": {"name": "", "checks": {}},
+  "metrics": {
+    "http_req_duration": {"avg": 400, "min": 300, "med": 400, "max": 500, "p(90)": 480, "p(95)": 490, "count": 2, "thresholds": {"p(95)<300": true}},
+    "http_reqs": {"count": 6, "rate": 0.5},
+    "http_req_failed": {"passes": 0, "fails": 6, "value": 0, "thresholds": {"rate<0.3": false}},
+    "vus": {"value": 1, "min": 1, "max": 10},
+    "checks": {"passes": 5, "fails": 1, "value": 0.8333, "thresholds": {"rate>0.8": false}}
+  }
+}`
+
+func TestSummaryWithExports(t *testing.T) {
+	dir := t.TempDir()
+
+	paths := []string{filepath.Join(dir, "a.json"), filepath.Join(dir, "b.json"), filepath.Join(dir, "summary.json")}
+	for i, data := range append(slices.Clone(instanceOutputs), summaryExport) {
+		require.NoError(t, os.WriteFile(paths[i], []byte(data), 0600))
+	}
+
+	a := New()
+	for _, path := range paths {
+		require.NoError(t, a.AddFile(path))
+	}
+
+	summary := a.Summary([]string{"avg", "min", "max", "med", "p(95)", "count"})
+
+	// exact stats are merged exactly, percentiles are left out and their
+	// thresholds fail
+	assert.Equal(t, map[string]float64{"avg": 300, "min": 100, "max": 500, "count": 6}, summary.Metrics["http_req_duration"].Values)
+	assert.Equal(t, map[string]bool{"p(95)<300": true}, summary.Metrics["http_req_duration"].Thresholds)
+	assert.Equal(t, map[string]float64{"count": 10, "rate": 10.0 / 12}, summary.Metrics["http_reqs"].Values)
+	assert.Equal(t, map[string]float64{"passes": 1, "fails": 9, "value": 0.1}, summary.Metrics["http_req_failed"].Values)
+	assert.Equal(t, map[string]bool{"rate<0.3": false}, summary.Metrics["http_req_failed"].Thresholds)
+	assert.Equal(t, map[string]float64{"value": 1, "min": 1, "max": 10}, summary.Metrics["vus"].Values)
+
+	// metrics of a single source are kept as is
+	assert.Equal(t, map[string]bool{"rate>0.8": false}, summary.Metrics["checks"].Thresholds)
+
+	assert.Equal(t, map[string][]string{"http_req_duration": {"med", "p(95)"}}, a.Inexact())
+}
+
+func TestMergeTrendWithoutCounts(t *testing.T) {
+	metric, inexact := mergeParts([]part{
+		{kind: typeTrend, values: map[string]float64{"avg": 100, "p(99)": 300, "min": 10, "max": 400}, thresholds: map[string]bool{"p(99.9)<1000": true, "max<1000": false}},
+		{kind: typeTrend, values: map[string]float64{"avg": 200, "p(99)": 250, "max": 300}, thresholds: map[string]bool{"avg<500": false}},
+	})
+
+	assert.Equal(t, []string{"avg", "p(99)"}, inexact)
+	assert.Equal(t, map[string]float64{"max": 400}, metric.Values)
+	// thresholds on stats that can't be merged fail, even if every part passed
+	assert.Equal(t, map[string]bool{"p(99.9)<1000": true, "avg<500": true, "max<1000": false}, metric.Thresholds)
+}
@@ -0,0 +1,26 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package merge
+
+import (
+	"fmt"
+
+	"github.com/go-vela/vela-k6/stats"
+)
+
+// evaluateThreshold returns true if the threshold expression passes on
+// the stats returned by stat, which returns false for a stat the metric
+// doesn't have.
+func evaluateThreshold(expression string, stat func(name string) (float64, bool)) (bool, error) {
+	threshold, err := stats.ParseThreshold(expression)
+	if err != nil {
+		return false, err
+	}
+
+	actual, ok := stat(threshold.Stat)
+	if !ok {
+		return false, fmt.Errorf("%q: the metric has no %s stat", expression, threshold.Stat)
+	}
+
+	return threshold.Passes(actual), nil
+}
@@ -0,0 +1,61 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package models
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Gate is a quality gate evaluated by the plugin against the end-of-test
+// summary, independently of the thresholds evaluated by k6.
+type Gate struct {
+	// Metric is the name of the metric, e.g. "http_req_duration".
+	Metric string `json:"metric"`
+	// Stat is the summary stat of the metric, e.g. "p(95)" or "rate".
+	Stat string `json:"stat"`
+	// Max is the highest accepted value of the stat, if set.
+	Max *float64 `json:"max,omitempty"`
+	// Min is the lowest accepted value of the stat, if set.
+	Min *float64 `json:"min,omitempty"`
+}
+
+// String returns the gate as a readable condition, such as
+// "http_req_duration p(95) <= 500".
+func (g Gate) String() string {
+	conditions := []string{}
+
+	if g.Min != nil {
+		conditions = append(conditions, fmt.Sprintf(">= %g", *g.Min))
+	}
+
+	if g.Max != nil {
+		conditions = append(conditions, fmt.Sprintf("<= %g", *g.Max))
+	}
+
+	return fmt.Sprintf("%s %s %s", g.Metric, g.Stat, strings.Join(conditions, " and "))
+}
+
+// Evaluate checks the gate against summary. It returns false and the
+// reason if the stat is missing or out of bounds.
+func (g Gate) Evaluate(summary *Summary) (bool, string) {
+	if summary == nil {
+		return false, fmt.Sprintf("%s: no summary available", g)
+	}
+
+	metric, ok := summary.Metrics[g.Metric]
+	if !ok {
+		return false, fmt.Sprintf("%s: metric not found in summary", g)
+	}
+
+	value, ok := metric.Value(g.Stat)
+	if !ok {
+		return false, fmt.Sprintf("%s: stat not found in summary", g)
+	}
+
+	if (g.Max != nil && value > *g.Max) || (g.Min != nil && value < *g.Min) {
+		return false, fmt.Sprintf("%s: got %g", g, value)
+	}
+
+	return true, ""
+}
@@ -0,0 +1,39 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package models
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGateEvaluate(t *testing.T) {
+	summary := &Summary{}
+	require.NoError(t, json.Unmarshal([]byte(summaryJSON), summary))
+
+	limit := func(v float64) *float64 { return &v }
+
+	for _, tc := range []struct {
+		gate   Gate
+		ok     bool
+		reason string
+	}{
+		{gate: Gate{Metric: "http_req_duration", Stat: "p(95)", Max: limit(300)}, ok: true},
+		{gate: Gate{Metric: "http_req_duration", Stat: "p(95)", Max: limit(200)}, reason: "http_req_duration p(95) <= 200: got 250"},
+		{gate: Gate{Metric: "checks", Stat: "value", Min: limit(0.995)}, reason: "checks value >= 0.995: got 0.99"},
+		{gate: Gate{Metric: "http_reqs", Stat: "rate", Min: limit(5), Max: limit(20)}, ok: true},
+		{gate: Gate{Metric: "iterations", Stat: "count", Min: limit(1)}, reason: "metric not found in summary"},
+		{gate: Gate{Metric: "http_reqs", Stat: "p(95)", Max: limit(1)}, reason: "stat not found in summary"},
+	} {
+		ok, reason := tc.gate.Evaluate(summary)
+		assert.Equal(t, tc.ok, ok, tc.gate.String())
+		assert.Contains(t, reason, tc.reason, tc.gate.String())
+	}
+
+	ok, reason := Gate{Metric: "checks", Stat: "value", Min: limit(1)}.Evaluate(nil)
+	assert.False(t, ok)
+	assert.Contains(t, reason, "no summary available")
+}
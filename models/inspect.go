@@ -0,0 +1,85 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package models
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+)
+
+// InspectResult is the output of `k6 inspect --execution-requirements`.
+type InspectResult struct {
+	Options       ScriptOptions `json:"options"`
+	TotalDuration string        `json:"totalDuration"`
+	MaxVUs        int           `json:"maxVUs"`
+}
+
+// ScriptOptions holds the consolidated k6 options of a script that are
+// used by the plugin.
+type ScriptOptions struct {
+	Scenarios         map[string]Scenario    `json:"scenarios"`
+	Thresholds        map[string][]Threshold `json:"thresholds"`
+	SummaryTrendStats []string               `json:"summaryTrendStats"`
+}
+
+// Scenario is a single k6 scenario.
+type Scenario struct {
+	Executor string `json:"executor"`
+	Exec     string `json:"exec,omitempty"`
+}
+
+// Threshold is a single k6 threshold expression. It may be written either
+// as a plain string or as an object with abort settings.
+type Threshold struct {
+	Threshold      string `json:"threshold"`
+	AbortOnFail    bool   `json:"abortOnFail,omitempty"`
+	DelayAbortEval string `json:"delayAbortEval,omitempty"`
+}
+
+// UnmarshalJSON decodes a threshold from either its string or object form.
+func (t *Threshold) UnmarshalJSON(data []byte) error {
+	var expression string
+	if err := json.Unmarshal(data, &expression); err == nil {
+		*t = Threshold{Threshold: expression}
+		return nil
+	}
+
+	type threshold Threshold
+
+	var obj threshold
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return err
+	}
+
+	if obj.Threshold == "" {
+		return errors.New("threshold object is missing the 'threshold' expression")
+	}
+
+	*t = Threshold(obj)
+
+	return nil
+}
+
+// MarshalJSON encodes a threshold as a plain string unless abort settings
+// are present. Comparison operators are not HTML escaped, so generated
+// configs and modules read like the thresholds they were written from.
+func (t Threshold) MarshalJSON() ([]byte, error) {
+	type threshold Threshold
+
+	var v any = threshold(t)
+	if !t.AbortOnFail && t.DelayAbortEval == "" {
+		v = t.Threshold
+	}
+
+	var b bytes.Buffer
+
+	enc := json.NewEncoder(&b)
+	enc.SetEscapeHTML(false)
+
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+
+	return bytes.TrimSpace(b.Bytes()), nil
+}
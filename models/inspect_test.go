@@ -0,0 +1,36 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package models
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestThresholdUnmarshalJSON(t *testing.T) {
+	var thresholds map[string][]Threshold
+
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"http_req_duration": ["p(95)<500", {"threshold": "p(99)<1000", "abortOnFail": true, "delayAbortEval": "10s"}]
+	}`), &thresholds))
+
+	assert.Equal(t, []Threshold{
+		{Threshold: "p(95)<500"},
+		{Threshold: "p(99)<1000", AbortOnFail: true, DelayAbortEval: "10s"},
+	}, thresholds["http_req_duration"])
+
+	assert.Error(t, json.Unmarshal([]byte(`{"abortOnFail": true}`), &Threshold{}))
+	assert.Error(t, json.Unmarshal([]byte(`1`), &Threshold{}))
+}
+
+func TestThresholdMarshalJSON(t *testing.T) {
+	b, err := json.Marshal([]Threshold{
+		{Threshold: "p(95)<500"},
+		{Threshold: "rate<0.01", AbortOnFail: true},
+	})
+	require.NoError(t, err)
+	assert.JSONEq(t, `["p(95)<500", {"threshold": "rate<0.01", "abortOnFail": true}]`, string(b))
+}
@@ -0,0 +1,19 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package models
+
+// K6Version is the version of a k6 binary and the xk6 extensions it was
+// built with, as reported by `k6 version`.
+type K6Version struct {
+	Version    string        `json:"version"`
+	Extensions []K6Extension `json:"extensions,omitempty"`
+}
+
+// K6Extension is an xk6 extension of a k6 binary, such as the
+// `k6/x/sql` JavaScript module of github.com/grafana/xk6-sql.
+type K6Extension struct {
+	Module  string `json:"module"`
+	Version string `json:"version"`
+	Name    string `json:"name"`
+	Type    string `json:"type"`
+}
@@ -0,0 +1,35 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package models
+
+// NotifyConfig is the configuration of the 'notify' plugin parameter,
+// which sends a message to one or more webhooks after the tests run.
+type NotifyConfig struct {
+	// Webhooks is the list of webhooks the message is sent to.
+	Webhooks []Webhook `json:"webhooks"`
+	// NotifyOn is the policy deciding when a message is sent. One of
+	// "always", "failure", "breach" or "regression".
+	NotifyOn string `json:"notify_on"`
+	// Template is a Go text/template used to render the message. For
+	// webhooks using the "json" format it renders the whole payload.
+	Template string `json:"template"`
+	// BaselinePath is the path to a k6 summary export from a previous
+	// run that the current summary is compared against for regressions.
+	BaselinePath string `json:"baseline_path"`
+	// RegressionTolerance is the percentage a stat may worsen compared
+	// to the baseline before it is reported as a regression.
+	RegressionTolerance float64 `json:"regression_tolerance"`
+}
+
+// Webhook is a single notification target.
+type Webhook struct {
+	// URL is the webhook URL.
+	URL string `json:"url"`
+	// URLEnv is the name of an environment variable holding the webhook
+	// URL, which allows the URL to be provided as a Vela secret.
+	URLEnv string `json:"url_env"`
+	// Format is the payload format. One of "slack", "teams" or "json".
+	Format string `json:"format"`
+	// Template overrides NotifyConfig.Template for this webhook.
+	Template string `json:"template"`
+}
@@ -0,0 +1,29 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package models
+
+// Profile is a named load profile applied to the k6 run, such as "smoke"
+// or "soak", so the same script can be run with consistent load shapes.
+type Profile struct {
+	// VUs is the number of virtual users, passed as --vus.
+	VUs int `json:"vus,omitempty"`
+	// Duration is the duration of the run, passed as --duration.
+	Duration string `json:"duration,omitempty"`
+	// Stages ramp the number of virtual users, passed as --stage. They
+	// can't be combined with Duration.
+	Stages []Stage `json:"stages,omitempty"`
+	// Thresholds are added to the thresholds of the run.
+	Thresholds map[string][]Threshold `json:"thresholds,omitempty"`
+	// Env is added to the environment variables of the script.
+	Env map[string]string `json:"env,omitempty"`
+	// Outputs are added to the outputs of the run.
+	Outputs []string `json:"outputs,omitempty"`
+}
+
+// Stage is a single ramping stage of a load profile.
+type Stage struct {
+	// Duration is the duration of the stage, e.g. "30s".
+	Duration string `json:"duration"`
+	// Target is the number of virtual users at the end of the stage.
+	Target int `json:"target"`
+}
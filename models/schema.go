@@ -0,0 +1,203 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package models
+
+import (
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// PlanSchema is the JSON Schema of the test plan file referenced by the
+// 'config_path' plugin parameter.
+//
+//go:embed plan.schema.json
+var PlanSchema []byte
+
+// ValidatePlan validates a decoded test plan against PlanSchema and
+// returns every violation at once.
+func ValidatePlan(plan any) error {
+	var schema map[string]any
+	if err := json.Unmarshal(PlanSchema, &schema); err != nil {
+		return fmt.Errorf("decode plan schema: %w", err)
+	}
+
+	return ValidateSchema(schema, plan)
+}
+
+// ValidateSchema validates doc, as decoded by encoding/json, against a
+// JSON Schema. Only the subset of JSON Schema used by the plugin is
+// supported: type, enum, minimum, properties, required,
+// additionalProperties, items and anyOf.
+func ValidateSchema(schema map[string]any, doc any) error {
+	return errors.Join(validateNode(schema, doc, "")...)
+}
+
+// validateNode validates value against schema, reporting violations at
+// the JSON pointer path.
+func validateNode(schema map[string]any, value any, path string) []error {
+	if types, ok := schema["type"]; ok && !matchesType(types, value) {
+		return []error{fmt.Errorf("%s: expected %s, got %s", pointer(path), describeTypes(types), typeOf(value))}
+	}
+
+	var errs []error
+
+	if enum, ok := schema["enum"].([]any); ok && !slices.Contains(enum, value) {
+		errs = append(errs, fmt.Errorf("%s: %v must be one of %v", pointer(path), value, enum))
+	}
+
+	if minimum, ok := schema["minimum"].(float64); ok {
+		if n, ok := value.(float64); ok && n < minimum {
+			errs = append(errs, fmt.Errorf("%s: %v must be at least %v", pointer(path), n, minimum))
+		}
+	}
+
+	if anyOf, ok := schema["anyOf"].([]any); ok && !matchesAny(anyOf, value, path) {
+		errs = append(errs, fmt.Errorf("%s: does not match any of the allowed forms", pointer(path)))
+	}
+
+	switch v := value.(type) {
+	case map[string]any:
+		errs = append(errs, validateObject(schema, v, path)...)
+	case []any:
+		if items, ok := schema["items"].(map[string]any); ok {
+			for i, item := range v {
+				errs = append(errs, validateNode(items, item, fmt.Sprintf("%s/%d", path, i))...)
+			}
+		}
+	}
+
+	return errs
+}
+
+// validateObject validates the properties of an object.
+func validateObject(schema map[string]any, obj map[string]any, path string) []error {
+	var errs []error
+
+	if required, ok := schema["required"].([]any); ok {
+		for _, name := range required {
+			if _, ok := obj[name.(string)]; !ok {
+				errs = append(errs, fmt.Errorf("%s: missing required property %q", pointer(path), name))
+			}
+		}
+	}
+
+	properties, _ := schema["properties"].(map[string]any)
+
+	keys := make([]string, 0, len(obj))
+	for key := range obj {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		childPath := path + "/" + key
+
+		if property, ok := properties[key].(map[string]any); ok {
+			errs = append(errs, validateNode(property, obj[key], childPath)...)
+			continue
+		}
+
+		switch additional := schema["additionalProperties"].(type) {
+		case bool:
+			if !additional {
+				errs = append(errs, fmt.Errorf("%s: unknown property", pointer(childPath)))
+			}
+		case map[string]any:
+			errs = append(errs, validateNode(additional, obj[key], childPath)...)
+		}
+	}
+
+	return errs
+}
+
+// matchesAny returns true if value is valid against any of the schemas.
+func matchesAny(schemas []any, value any, path string) bool {
+	for _, s := range schemas {
+		if schema, ok := s.(map[string]any); ok && len(validateNode(schema, value, path)) == 0 {
+			return true
+		}
+	}
+
+	return false
+}
+
+// matchesType returns true if value has one of the JSON Schema types.
+func matchesType(types any, value any) bool {
+	switch t := types.(type) {
+	case string:
+		return isType(t, value)
+	case []any:
+		for _, name := range t {
+			if s, ok := name.(string); ok && isType(s, value) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// isType returns true if value has the JSON Schema type name.
+func isType(name string, value any) bool {
+	switch name {
+	case "integer":
+		n, ok := value.(float64)
+		return ok && n == math.Trunc(n)
+	default:
+		return typeOf(value) == name || (name == "number" && typeOf(value) == "integer")
+	}
+}
+
+// typeOf returns the JSON Schema type name of a decoded JSON value.
+func typeOf(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		if v == math.Trunc(v) {
+			return "integer"
+		}
+
+		return "number"
+	case string:
+		return "string"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	default:
+		return fmt.Sprintf("%T", value)
+	}
+}
+
+// describeTypes formats the schema type keyword for error messages.
+func describeTypes(types any) string {
+	if list, ok := types.([]any); ok {
+		names := make([]string, 0, len(list))
+		for _, name := range list {
+			names = append(names, fmt.Sprint(name))
+		}
+
+		return strings.Join(names, " or ")
+	}
+
+	return fmt.Sprint(types)
+}
+
+// pointer returns the JSON pointer for path, using "/" for the root.
+func pointer(path string) string {
+	if path == "" {
+		return "/"
+	}
+
+	return path
+}
@@ -0,0 +1,56 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package models
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateSchema(t *testing.T) {
+	schema := map[string]any{}
+	require.NoError(t, json.Unmarshal([]byte(`{
+  "type": "object",
+  "required": ["name"],
+  "properties": {
+    "name": {"type": "string"},
+    "count": {"type": "integer", "minimum": 1},
+    "mode": {"enum": ["a", "b"]},
+    "items": {"type": "array", "items": {"anyOf": [{"type": "string"}, {"type": "object", "required": ["id"]}]}},
+    "labels": {"type": "object", "additionalProperties": {"type": ["string", "number"]}}
+  },
+  "additionalProperties": false
+}`), &schema))
+
+	validate := func(doc string) error {
+		var value any
+		require.NoError(t, json.Unmarshal([]byte(doc), &value))
+
+		return ValidateSchema(schema, value)
+	}
+
+	assert.NoError(t, validate(`{"name": "x", "count": 2, "mode": "a", "items": ["s", {"id": 1}], "labels": {"a": "b", "c": 1.5}}`))
+
+	err := validate(`{"count": 0.5, "mode": "c", "items": [1], "labels": {"a": true}, "extra": 1}`)
+	assert.ErrorContains(t, err, `/: missing required property "name"`)
+	assert.ErrorContains(t, err, "/count: expected integer, got number")
+	assert.ErrorContains(t, err, "/mode: c must be one of [a b]")
+	assert.ErrorContains(t, err, "/items/0: does not match any of the allowed forms")
+	assert.ErrorContains(t, err, "/labels/a: expected string or number, got boolean")
+	assert.ErrorContains(t, err, "/extra: unknown property")
+
+	assert.ErrorContains(t, validate(`{"name": "x", "count": 0}`), "/count: 0 must be at least 1")
+	assert.ErrorContains(t, validate(`[]`), "/: expected object, got array")
+}
+
+func TestValidatePlan(t *testing.T) {
+	var plan any
+	require.NoError(t, json.Unmarshal([]byte(`{"script_path": "./k6/load.js", "scripts": [{"path": "./k6/load.js", "env": {"VUS": 10}}]}`), &plan))
+	assert.NoError(t, ValidatePlan(plan))
+
+	require.NoError(t, json.Unmarshal([]byte(`{"scripts": [{"path": "./k6/load.js", "log_progress": true}]}`), &plan))
+	assert.ErrorContains(t, ValidatePlan(plan), "/scripts/0/log_progress: unknown property")
+}
@@ -0,0 +1,115 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+)
+
+// Summary is the end-of-test summary written by k6 with the
+// --summary-export flag.
+type Summary struct {
+	Metrics map[string]Metric `json:"metrics"`
+}
+
+// Metric holds the aggregated values of a single k6 metric from a
+// Summary, along with the result of each threshold defined on it.
+type Metric struct {
+	// Values maps a stat name (e.g. "avg", "p(95)", "count", "rate",
+	// "value") to its aggregated value.
+	Values map[string]float64
+	// Thresholds maps a threshold expression (e.g. "p(95)<500") to
+	// true if the threshold failed.
+	Thresholds map[string]bool
+}
+
+// UnmarshalJSON decodes a metric from the flat k6 summary export format,
+// where the stats and the thresholds object share the same object.
+func (m *Metric) UnmarshalJSON(data []byte) error {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	m.Values = make(map[string]float64, len(raw))
+
+	for key, value := range raw {
+		if key == "thresholds" {
+			if err := json.Unmarshal(value, &m.Thresholds); err != nil {
+				return fmt.Errorf("decode thresholds: %w", err)
+			}
+
+			continue
+		}
+
+		var f float64
+		if err := json.Unmarshal(value, &f); err != nil {
+			// non-numeric stats are not used by the plugin
+			continue
+		}
+
+		m.Values[key] = f
+	}
+
+	return nil
+}
+
+// MarshalJSON encodes a metric in the flat k6 summary export format.
+func (m Metric) MarshalJSON() ([]byte, error) {
+	raw := make(map[string]any, len(m.Values)+1)
+	for key, value := range m.Values {
+		raw[key] = value
+	}
+
+	if len(m.Thresholds) > 0 {
+		raw["thresholds"] = m.Thresholds
+	}
+
+	return json.Marshal(raw)
+}
+
+// Value returns the value of the given stat and whether it is present.
+func (m Metric) Value(stat string) (float64, bool) {
+	v, ok := m.Values[stat]
+	return v, ok
+}
+
+// ReadSummary reads and decodes the k6 summary export at path.
+func ReadSummary(path string) (*Summary, error) {
+	data, err := os.ReadFile(path) //nolint:gosec // path is validated by the plugin configuration
+	if err != nil {
+		return nil, err
+	}
+
+	summary := &Summary{}
+	if err := json.Unmarshal(data, summary); err != nil {
+		return nil, fmt.Errorf("decode summary %s: %w", path, err)
+	}
+
+	return summary, nil
+}
+
+// BreachedThresholds returns the failed thresholds in the summary
+// formatted as "metric: expression", sorted alphabetically.
+func (s *Summary) BreachedThresholds() []string {
+	if s == nil {
+		return nil
+	}
+
+	var breached []string
+
+	for name, metric := range s.Metrics {
+		for expression, failed := range metric.Thresholds {
+			if failed {
+				breached = append(breached, fmt.Sprintf("%s: %s", name, expression))
+			}
+		}
+	}
+
+	sort.Strings(breached)
+
+	return breached
+}
@@ -0,0 +1,81 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package models
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const summaryJSON = `{
+  "root_group": {"name": "", "path": "", "groups": {}, "checks": {}},
+  "metrics": {
+    "http_req_duration": {
+      "avg": 120.5,
+      "p(95)": 250,
+      "thresholds": {"p(95)<200": true, "avg<500": false}
+    },
+    "http_reqs": {"count": 100, "rate": 10.5},
+    "checks": {"passes": 99, "fails": 1, "value": 0.99}
+  }
+}`
+
+func TestMetricUnmarshalJSON(t *testing.T) {
+	summary := &Summary{}
+	require.NoError(t, json.Unmarshal([]byte(summaryJSON), summary))
+
+	duration := summary.Metrics["http_req_duration"]
+	p95, ok := duration.Value("p(95)")
+	assert.True(t, ok)
+	assert.InDelta(t, 250, p95, 0)
+	assert.True(t, duration.Thresholds["p(95)<200"])
+	assert.False(t, duration.Thresholds["avg<500"])
+
+	_, ok = summary.Metrics["http_reqs"].Value("p(95)")
+	assert.False(t, ok)
+
+	assert.Error(t, json.Unmarshal([]byte(`{"metrics": {"x": {"thresholds": 1}}}`), &Summary{}))
+}
+
+func TestMetricMarshalJSON(t *testing.T) {
+	m := Metric{
+		Values:     map[string]float64{"avg": 1.5},
+		Thresholds: map[string]bool{"avg<2": false},
+	}
+
+	b, err := json.Marshal(m)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"avg": 1.5, "thresholds": {"avg<2": false}}`, string(b))
+}
+
+func TestReadSummary(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "summary.json")
+	require.NoError(t, os.WriteFile(path, []byte(summaryJSON), 0600))
+
+	summary, err := ReadSummary(path)
+	require.NoError(t, err)
+	assert.Len(t, summary.Metrics, 3)
+
+	_, err = ReadSummary(filepath.Join(dir, "missing.json"))
+	assert.Error(t, err)
+
+	require.NoError(t, os.WriteFile(path, []byte("not json"), 0600))
+	_, err = ReadSummary(path)
+	assert.ErrorContains(t, err, "decode summary")
+}
+
+func TestBreachedThresholds(t *testing.T) {
+	summary := &Summary{}
+	require.NoError(t, json.Unmarshal([]byte(summaryJSON), summary))
+
+	assert.Equal(t, []string{"http_req_duration: p(95)<200"}, summary.BreachedThresholds())
+
+	var empty *Summary
+	assert.Empty(t, empty.BreachedThresholds())
+}
@@ -0,0 +1,288 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package plugin
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-vela/vela-k6/models"
+	"github.com/go-vela/vela-k6/stats"
+)
+
+// abortEvalInterval is how often the abort conditions are evaluated.
+const abortEvalInterval = time.Second
+
+// abortConditionPattern matches an abort condition, such as
+// "http_req_failed rate > 0.2 for 60s": a metric with an optional tag
+// filter, a stat, a comparison, a value and an optional window.
+var abortConditionPattern = regexp.MustCompile(`^\s*([a-zA-Z0-9_.\-]+)(\{[^{}]+\})?\s+(avg|min|max|med|count|rate|p\(\d+(?:\.\d+)?\))\s*(<=|>=|<|>|==|!=)\s*(-?\d+(?:\.\d+)?)(?:\s+for\s+(\S+))?\s*$`)
+
+// abortCondition is a parsed entry of 'abort_conditions'. The run is
+// aborted when the stat of the metric samples compares true to the value.
+// With a window, the stat is computed over the samples of the last window
+// once the run has lasted that long, otherwise over every sample.
+type abortCondition struct {
+	Expression string
+	Metric     string
+	Tags       map[string]string
+	Stat       string
+	Operator   string
+	Value      float64
+	Window     time.Duration
+}
+
+// parseAbortCondition parses a single abort condition.
+func parseAbortCondition(expression string) (abortCondition, error) {
+	m := abortConditionPattern.FindStringSubmatch(expression)
+	if m == nil {
+		return abortCondition{}, fmt.Errorf("%q is not an abort condition (use \"<metric> <stat> <operator> <value> [for <duration>]\")", expression)
+	}
+
+	cond := abortCondition{Expression: strings.TrimSpace(expression), Metric: m[1], Stat: m[3], Operator: m[4]}
+
+	if m[2] != "" {
+		cond.Tags = map[string]string{}
+
+		for _, filter := range strings.Split(strings.Trim(m[2], "{}"), ",") {
+			key, value, ok := strings.Cut(filter, ":")
+			if !ok {
+				return abortCondition{}, fmt.Errorf("%q: tag filter %q must be key:value", expression, filter)
+			}
+
+			cond.Tags[strings.TrimSpace(key)] = strings.TrimSpace(value)
+		}
+	}
+
+	value, err := strconv.ParseFloat(m[5], 64)
+	if err != nil {
+		return abortCondition{}, fmt.Errorf("%q: %w", expression, err)
+	}
+
+	cond.Value = value
+
+	if m[6] != "" {
+		window, err := time.ParseDuration(m[6])
+		if err != nil || window <= 0 {
+			return abortCondition{}, fmt.Errorf("%q: %q is not a duration", expression, m[6])
+		}
+
+		cond.Window = window
+	}
+
+	return cond, nil
+}
+
+// setAbortConditions parses and stores the 'abort_conditions' parameter.
+func setAbortConditions(cfg *config, v any) error {
+	var errs []error
+
+	for _, expression := range v.([]string) {
+		cond, err := parseAbortCondition(expression)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		cfg.AbortConditions = append(cfg.AbortConditions, cond)
+	}
+
+	return errors.Join(errs...)
+}
+
+// MarshalJSON encodes the condition as its expression in the effective
+// configuration.
+func (c abortCondition) MarshalJSON() ([]byte, error) {
+	return []byte(strconv.Quote(c.Expression)), nil
+}
+
+// matches returns true if the sample belongs to the metric and tags of
+// the condition.
+func (c abortCondition) matches(point metricPoint) bool {
+	if point.Metric != c.Metric {
+		return false
+	}
+
+	for key, value := range c.Tags {
+		if point.Tags[key] != value {
+			return false
+		}
+	}
+
+	return true
+}
+
+// violated returns true if the stat compares true to the value.
+func (c abortCondition) violated(stat float64) bool {
+	return stats.Compare(stat, c.Operator, c.Value)
+}
+
+// stat computes the stat of the condition over the samples of h.
+func (c abortCondition) stat(h *stats.Histogram) float64 {
+	switch c.Stat {
+	case "avg":
+		return h.Avg()
+	case "min":
+		return h.Min
+	case "max":
+		return h.Max
+	case "med":
+		return h.Percentile(50)
+	case "count":
+		return h.Sum
+	case "rate":
+		return float64(h.NonZero) / float64(h.Count)
+	default:
+		p, _ := stats.ParsePercentile(c.Stat)
+		return h.Percentile(p)
+	}
+}
+
+// abortMonitor evaluates the abort conditions over the k6 metrics stream
+// and calls abort once, with the first violated condition. The samples of
+// a condition without a window are aggregated over the whole run, those
+// of a windowed condition per abortEvalInterval, so memory doesn't grow
+// with the number of samples.
+type abortMonitor struct {
+	mu         sync.Mutex
+	start      time.Time
+	conditions []abortCondition
+	totals     []*stats.Histogram
+	windows    []map[int64]*stats.Histogram
+	abort      func(abortCondition)
+	triggered  *abortCondition
+	stop       chan struct{}
+	wg         sync.WaitGroup
+}
+
+// newAbortMonitor returns a monitor for a run started at start.
+func newAbortMonitor(start time.Time, conditions []abortCondition, abort func(abortCondition)) *abortMonitor {
+	m := &abortMonitor{
+		start:      start,
+		conditions: conditions,
+		totals:     make([]*stats.Histogram, len(conditions)),
+		windows:    make([]map[int64]*stats.Histogram, len(conditions)),
+		abort:      abort,
+		stop:       make(chan struct{}),
+	}
+
+	for i, cond := range conditions {
+		if cond.Window > 0 {
+			m.windows[i] = map[int64]*stats.Histogram{}
+		} else {
+			m.totals[i] = stats.NewHistogram()
+		}
+	}
+
+	return m
+}
+
+// add records a sample of the metrics stream, at the time k6 took it.
+func (m *abortMonitor) add(point metricPoint) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i, cond := range m.conditions {
+		if !cond.matches(point) {
+			continue
+		}
+
+		if cond.Window == 0 {
+			m.totals[i].Add(point.Value)
+			continue
+		}
+
+		at := point.Time.Truncate(abortEvalInterval).UnixNano()
+		if m.windows[i][at] == nil {
+			m.windows[i][at] = stats.NewHistogram()
+		}
+
+		m.windows[i][at].Add(point.Value)
+	}
+}
+
+// evaluate returns the first condition violated at now, if any. Samples
+// older than the window of their condition are dropped.
+func (m *abortMonitor) evaluate(now time.Time) (abortCondition, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i, cond := range m.conditions {
+		samples := m.totals[i]
+
+		if cond.Window > 0 {
+			if now.Sub(m.start) < cond.Window {
+				continue
+			}
+
+			samples = stats.NewHistogram()
+
+			for at, interval := range m.windows[i] {
+				if now.Sub(time.Unix(0, at)) > cond.Window {
+					delete(m.windows[i], at)
+					continue
+				}
+
+				samples.Merge(interval)
+			}
+		}
+
+		if samples.Count > 0 && cond.violated(cond.stat(samples)) {
+			return cond, true
+		}
+	}
+
+	return abortCondition{}, false
+}
+
+// Start evaluates the conditions every abortEvalInterval until one is
+// violated or Stop is called.
+func (m *abortMonitor) Start() {
+	m.wg.Add(1)
+
+	go func() {
+		defer m.wg.Done()
+
+		ticker := time.NewTicker(abortEvalInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-m.stop:
+				return
+			case now := <-ticker.C:
+				if cond, ok := m.evaluate(now); ok {
+					m.mu.Lock()
+					m.triggered = &cond
+					m.mu.Unlock()
+
+					m.abort(cond)
+
+					return
+				}
+			}
+		}
+	}()
+}
+
+// Stop stops evaluating the conditions and returns the condition that
+// triggered the abort, if any.
+func (m *abortMonitor) Stop() *abortCondition {
+	close(m.stop)
+	m.wg.Wait()
+
+	return m.triggered
+}
+
+// abortRun stops the running k6 test gracefully because cond is violated.
+func (p *pluginType) abortRun(cmd models.ShellCommand, cond abortCondition) {
+	log.Printf("Abort condition %q violated, stopping k6...\n", cond.Expression)
+
+	p.stopRun(p.apiAddress(), cmd)
+}
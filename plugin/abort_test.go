@@ -0,0 +1,119 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package plugin
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/go-vela/vela-k6/stats"
+)
+
+func TestParseAbortCondition(t *testing.T) {
+	cond, err := parseAbortCondition("http_req_failed rate > 0.2 for 60s")
+	require.NoError(t, err)
+	assert.Equal(t, abortCondition{
+		Expression: "http_req_failed rate > 0.2 for 60s",
+		Metric:     "http_req_failed",
+		Stat:       "rate",
+		Operator:   ">",
+		Value:      0.2,
+		Window:     time.Minute,
+	}, cond)
+
+	cond, err = parseAbortCondition("http_req_duration{status:200, scenario:browse} p(99.9)>=1500")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"status": "200", "scenario": "browse"}, cond.Tags)
+	assert.Equal(t, "p(99.9)", cond.Stat)
+	assert.Zero(t, cond.Window)
+
+	for expression, expected := range map[string]string{
+		"http_req_failed > 0.2":               "is not an abort condition",
+		"http_req_failed rate > 0.2 for ever": `"ever" is not a duration`,
+		"http_req_failed rate > 0.2 for 0s":   `"0s" is not a duration`,
+		"http_req_duration{status} avg > 100": "tag filter \"status\" must be key:value",
+	} {
+		_, err := parseAbortCondition(expression)
+		assert.ErrorContains(t, err, expected, expression)
+	}
+}
+
+func TestSetAbortConditions(t *testing.T) {
+	cfg := &config{}
+	require.NoError(t, setAbortConditions(cfg, []string{"checks rate < 0.9", "iterations count >= 1000"}))
+	assert.Len(t, cfg.AbortConditions, 2)
+
+	data, err := json.Marshal(cfg.AbortConditions)
+	require.NoError(t, err)
+	assert.JSONEq(t, `["checks rate < 0.9", "iterations count >= 1000"]`, string(data))
+
+	err = setAbortConditions(&config{}, []string{"bad", "worse"})
+	assert.ErrorContains(t, err, `"bad" is not an abort condition`)
+	assert.ErrorContains(t, err, `"worse" is not an abort condition`)
+}
+
+func TestAbortConditionStat(t *testing.T) {
+	samples := stats.NewHistogram()
+	for _, value := range []float64{0, 1, 1, 0, 3} {
+		samples.Add(value)
+	}
+
+	for stat, expected := range map[string]float64{
+		"avg":   1,
+		"min":   0,
+		"max":   3,
+		"med":   1,
+		"count": 5,
+		"rate":  0.6,
+		"p(50)": 1,
+		"p(90)": 2.2,
+	} {
+		assert.InDelta(t, expected, abortCondition{Stat: stat}.stat(samples), 0.02, stat)
+	}
+}
+
+func TestAbortMonitor(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	failed, err := parseAbortCondition("http_req_failed{scenario:api} rate > 0.2 for 60s")
+	require.NoError(t, err)
+	slow, err := parseAbortCondition("http_req_duration max > 5000")
+	require.NoError(t, err)
+
+	m := newAbortMonitor(start, []abortCondition{failed, slow}, nil)
+
+	// failures before the window of the last minute are dropped
+	for i := range 10 {
+		m.add(metricPoint{Metric: "http_req_failed", Value: 1, Tags: map[string]string{"scenario": "api"}, Time: start.Add(time.Duration(i) * time.Second)})
+	}
+
+	m.add(metricPoint{Metric: "http_req_failed", Value: 1, Tags: map[string]string{"scenario": "web"}, Time: start.Add(30 * time.Second)})
+
+	_, ok := m.evaluate(start.Add(30 * time.Second))
+	assert.False(t, ok, "window not full")
+
+	for i := range 10 {
+		m.add(metricPoint{Metric: "http_req_failed", Value: 0, Tags: map[string]string{"scenario": "api"}, Time: start.Add(time.Duration(60+i) * time.Second)})
+	}
+
+	_, ok = m.evaluate(start.Add(70 * time.Second))
+	assert.False(t, ok)
+
+	m.add(metricPoint{Metric: "http_req_failed", Value: 1, Tags: map[string]string{"scenario": "api"}, Time: start.Add(71 * time.Second)})
+	m.add(metricPoint{Metric: "http_req_failed", Value: 1, Tags: map[string]string{"scenario": "api"}, Time: start.Add(71 * time.Second)})
+	m.add(metricPoint{Metric: "http_req_failed", Value: 1, Tags: map[string]string{"scenario": "api"}, Time: start.Add(71 * time.Second)})
+
+	cond, ok := m.evaluate(start.Add(72 * time.Second))
+	require.True(t, ok)
+	assert.Equal(t, failed.Expression, cond.Expression)
+
+	m = newAbortMonitor(start, []abortCondition{failed, slow}, nil)
+	m.add(metricPoint{Metric: "http_req_duration", Value: 6000, Time: start})
+
+	cond, ok = m.evaluate(start.Add(time.Second))
+	require.True(t, ok)
+	assert.Equal(t, slow.Expression, cond.Expression)
+}
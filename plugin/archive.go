@@ -0,0 +1,108 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package plugin
+
+import (
+	"archive/tar"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// archiveMetadataFile is the file every k6 archive contains at its root.
+const archiveMetadataFile = "metadata.json"
+
+// isArchive returns true if path refers to a k6 archive bundle.
+func isArchive(path string) bool {
+	return strings.EqualFold(filepath.Ext(path), ".tar")
+}
+
+// verifyScript verifies the script exists and, if it is a k6 archive,
+// that it is a valid tar archive containing the k6 metadata.
+func (p *pluginType) verifyScript() error {
+	err := p.verifyFileExists(p.config.ScriptPath)
+	if err != nil {
+		return fmt.Errorf("read script file at %s: %w", p.config.ScriptPath, err)
+	}
+
+	if isArchive(p.config.ScriptPath) {
+		if err := validateArchive(p.config.ScriptPath); err != nil {
+			return fmt.Errorf("%w: %w", ErrInvalidConfig, err)
+		}
+	}
+
+	return nil
+}
+
+// validateArchive returns an error if the file at archivePath is not a
+// tar archive containing the metadata.json written by `k6 archive`.
+func validateArchive(archivePath string) error {
+	f, err := os.Open(archivePath) //nolint:gosec // path is validated by the plugin configuration
+	if err != nil {
+		return fmt.Errorf("open k6 archive %s: %w", archivePath, err)
+	}
+	defer f.Close()
+
+	reader := tar.NewReader(f)
+
+	for {
+		header, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			return fmt.Errorf("k6 archive %s does not contain %s", archivePath, archiveMetadataFile)
+		}
+
+		if err != nil {
+			return fmt.Errorf("k6 archive %s is not a valid tar archive: %w", archivePath, err)
+		}
+
+		if path.Clean(header.Name) == archiveMetadataFile {
+			return nil
+		}
+	}
+}
+
+// runScriptPath returns the path passed to `k6 run`. When an archive
+// output path is set, the archived bundle is run so the stored artifact
+// is exactly what was tested, otherwise the script or the module
+// overriding its thresholds.
+func (p *pluginType) runScriptPath() string {
+	if p.config.ArchiveOutputPath != "" {
+		return p.config.ArchiveOutputPath
+	}
+
+	return p.k6ScriptPath()
+}
+
+// k6ArchiveArgs returns the arguments passed to k6 to archive the script.
+func (p *pluginType) k6ArchiveArgs() []string {
+	args := append([]string{"archive"}, p.compatibilityArgs()...)
+
+	return append(args, "-O", p.config.ArchiveOutputPath, p.k6ScriptPath())
+}
+
+// archiveScript bundles the script with `k6 archive` into the archive
+// output path.
+func (p *pluginType) archiveScript() error {
+	if err := os.MkdirAll(filepath.Dir(p.config.ArchiveOutputPath), os.FileMode(0755)); err != nil {
+		return fmt.Errorf("create archive output directory: %w", err)
+	}
+
+	if err := p.writeThresholdsModule(); err != nil {
+		return err
+	}
+
+	log.Println("Archiving script...")
+
+	if err := streamCommand(p.buildCommand(p.k6Binary(), p.k6ArchiveArgs()...), p.newRedactor()); err != nil {
+		return fmt.Errorf("archive script: %w", err)
+	}
+
+	log.Printf("Archive saved at %s\n", p.config.ArchiveOutputPath)
+
+	return nil
+}
@@ -0,0 +1,96 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package plugin
+
+import (
+	"archive/tar"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/go-vela/vela-k6/plugin/mock"
+)
+
+// writeTar writes a tar archive containing the named empty files to path.
+func writeTar(t *testing.T, path string, names ...string) {
+	t.Helper()
+
+	f, err := os.Create(path)
+	require.NoError(t, err)
+
+	defer f.Close()
+
+	w := tar.NewWriter(f)
+	for _, name := range names {
+		require.NoError(t, w.WriteHeader(&tar.Header{Name: name, Mode: 0600, Typeflag: tar.TypeReg}))
+	}
+
+	require.NoError(t, w.Close())
+}
+
+func TestValidateArchive(t *testing.T) {
+	dir := t.TempDir()
+
+	valid := filepath.Join(dir, "valid.tar")
+	writeTar(t, valid, "./metadata.json", "data", "files/script.js")
+	assert.NoError(t, validateArchive(valid))
+
+	noMetadata := filepath.Join(dir, "no-metadata.tar")
+	writeTar(t, noMetadata, "data")
+	assert.ErrorContains(t, validateArchive(noMetadata), "does not contain metadata.json")
+
+	notTar := filepath.Join(dir, "not-tar.tar")
+	require.NoError(t, os.WriteFile(notTar, []byte("this is not a tar archive but is long enough to have a header"), 0600))
+	assert.ErrorContains(t, validateArchive(notTar), "is not a valid tar archive")
+
+	assert.ErrorContains(t, validateArchive(filepath.Join(dir, "missing.tar")), "open k6 archive")
+}
+
+func TestVerifyScript(t *testing.T) {
+	dir := t.TempDir()
+	archive := filepath.Join(dir, "bundle.tar")
+	writeTar(t, archive, "data")
+
+	p := &pluginType{config: config{ScriptPath: archive}, verifyFileExists: checkOSStat}
+	assert.ErrorIs(t, p.verifyScript(), ErrInvalidConfig)
+
+	p.config.ScriptPath = filepath.Join(dir, "missing.js")
+	assert.ErrorContains(t, p.verifyScript(), "read script file at")
+}
+
+func TestArchiveScript(t *testing.T) {
+	dir := t.TempDir()
+
+	t.Run("Runs Archive", func(t *testing.T) {
+		p := &pluginType{
+			config: config{
+				ScriptPath:        "./test/script.js",
+				ArchiveOutputPath: filepath.Join(dir, "artifacts", "bundle.tar"),
+			},
+			tempDir:          dir,
+			buildCommand:     mock.CommandBuilderWithError(nil, nil, nil, nil),
+			verifyFileExists: func(string) error { return nil },
+		}
+
+		assert.NoError(t, p.RunPerfTests())
+		assert.DirExists(t, filepath.Join(dir, "artifacts"))
+		assert.Equal(t, []string{"archive", "-O", p.config.ArchiveOutputPath, "./test/script.js"}, p.k6ArchiveArgs())
+		assert.Equal(t, []string{"run", "-q", "--log-format=json", "--address=localhost:6565", "--summary-export=" + filepath.Join(dir, summaryExportFile), p.config.ArchiveOutputPath}, p.k6RunArgs())
+	})
+	t.Run("Archive Error", func(t *testing.T) {
+		p := &pluginType{
+			config: config{
+				ScriptPath:        "./test/script.js",
+				ArchiveOutputPath: filepath.Join(dir, "bundle.tar"),
+			},
+			buildCommand:     mock.CommandBuilderWithError(errors.New("exit status 107"), nil, nil, nil),
+			verifyFileExists: func(string) error { return nil },
+		}
+
+		assert.ErrorContains(t, p.RunPerfTests(), "archive script: exit status 107")
+	})
+}
@@ -0,0 +1,230 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package plugin
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-vela/vela-k6/models"
+)
+
+// chromiumBinaries are the names the k6 browser module looks up Chromium
+// with on the PATH.
+var chromiumBinaries = []string{"chromium", "chromium-browser", "google-chrome", "google-chrome-stable"}
+
+// screenshotExtensions are the files collected as screenshots in browser
+// mode.
+var screenshotExtensions = []string{".png", ".jpeg", ".jpg"}
+
+// webVitalStat is the trend stat Web Vitals are assessed at, added to the
+// summary in browser mode.
+const webVitalStat = "p(75)"
+
+// webVitals are the Web Vitals reported after a browser run, with the
+// upper bounds of their "good" and "needs improvement" ratings.
+var webVitals = []struct {
+	metric, name, unit string
+	good, poor         float64
+}{
+	{"browser_web_vital_lcp", "LCP", "ms", 2500, 4000},
+	{"browser_web_vital_fcp", "FCP", "ms", 1800, 3000},
+	{"browser_web_vital_cls", "CLS", "", 0.1, 0.25},
+}
+
+// checkBrowser rejects the browser parameters when 'browser' is not
+// enabled.
+func (p *pluginType) checkBrowser() error {
+	if p.config.Browser {
+		return nil
+	}
+
+	set := map[string]bool{
+		"browser_executable_path": p.config.BrowserExecutablePath != "",
+		"browser_timeout":         p.config.BrowserTimeout > 0,
+		"screenshots_path":        p.config.ScreenshotsPath != "",
+	}
+
+	var errs []error
+
+	for _, name := range sortedKeys(set) {
+		if set[name] {
+			errs = append(errs, fmt.Errorf("'%s' requires 'browser'", name))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// browserEnv returns the environment variables configuring the k6
+// browser module, or nil if 'browser' is not enabled.
+func (p *pluginType) browserEnv() map[string]string {
+	if !p.config.Browser {
+		return nil
+	}
+
+	env := map[string]string{"K6_BROWSER_HEADLESS": strconv.FormatBool(p.config.BrowserHeadless)}
+
+	if len(p.config.BrowserArgs) > 0 {
+		env["K6_BROWSER_ARGS"] = strings.Join(p.config.BrowserArgs, ",")
+	}
+
+	if p.config.BrowserTimeout > 0 {
+		env["K6_BROWSER_TIMEOUT"] = time.Duration(p.config.BrowserTimeout).String()
+	}
+
+	if p.config.BrowserExecutablePath != "" {
+		env["K6_BROWSER_EXECUTABLE_PATH"] = p.config.BrowserExecutablePath
+	}
+
+	return env
+}
+
+// setBrowserEnv sets the browser environment variables, which k6
+// inherits.
+func (p *pluginType) setBrowserEnv() error {
+	env := p.browserEnv()
+
+	for _, key := range sortedKeys(env) {
+		if err := os.Setenv(key, env[key]); err != nil {
+			return fmt.Errorf("set %s: %w", key, err)
+		}
+	}
+
+	return nil
+}
+
+// findChromium returns the path of the Chromium the k6 browser module
+// runs: 'browser_executable_path', or the first Chromium on the PATH.
+func (p *pluginType) findChromium() (string, error) {
+	if p.config.BrowserExecutablePath != "" {
+		return p.config.BrowserExecutablePath, nil
+	}
+
+	for _, name := range chromiumBinaries {
+		if path, err := p.lookPath(name); err == nil {
+			return path, nil
+		}
+	}
+
+	return "", fmt.Errorf("'browser' requires Chromium, but none of %s is on the PATH (set 'browser_executable_path' or use an image with a browser)", strings.Join(chromiumBinaries, ", "))
+}
+
+// reportBrowserRun collects the screenshots written since started and
+// logs the Web Vitals of the run.
+func (p *pluginType) reportBrowserRun(started time.Time) {
+	if p.config.ScreenshotsPath != "" {
+		count, err := collectScreenshots(p.config.ScreenshotsPath, started)
+		if err != nil {
+			log.Printf("WARNING: collect screenshots: %s\n", err)
+		}
+
+		if count > 0 {
+			log.Printf("Collected %d screenshot(s) in %s\n", count, p.config.ScreenshotsPath)
+		}
+	}
+
+	logWebVitals(p.result.Summary)
+}
+
+// collectScreenshots moves the screenshots written in the working
+// directory since started into dir, keeping their relative paths. Hidden
+// directories, node_modules and dir itself are skipped. It returns the
+// number of screenshots moved.
+func collectScreenshots(dir string, started time.Time) (int, error) {
+	wd, err := os.Getwd()
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+
+	err = filepath.WalkDir(wd, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if entry.IsDir() {
+			if path != wd && (strings.HasPrefix(entry.Name(), ".") || entry.Name() == "node_modules" || path == dir) {
+				return filepath.SkipDir
+			}
+
+			return nil
+		}
+
+		if !slices.Contains(screenshotExtensions, strings.ToLower(filepath.Ext(path))) {
+			return nil
+		}
+
+		info, err := entry.Info()
+		if err != nil || info.ModTime().Before(started) {
+			return err
+		}
+
+		rel, err := filepath.Rel(wd, path)
+		if err != nil {
+			return err
+		}
+
+		target := filepath.Join(dir, rel)
+		if err := os.MkdirAll(filepath.Dir(target), os.FileMode(0755)); err != nil {
+			return err
+		}
+
+		if err := os.Rename(path, target); err != nil {
+			return err
+		}
+
+		count++
+
+		return nil
+	})
+
+	return count, err
+}
+
+// logWebVitals logs the p(75) of the Web Vitals in the summary, with
+// their rating.
+func logWebVitals(summary *models.Summary) {
+	if summary == nil {
+		return
+	}
+
+	var parts []string
+
+	for _, vital := range webVitals {
+		value, ok := summary.Metrics[vital.metric].Value(webVitalStat)
+		if !ok {
+			continue
+		}
+
+		rating := "good"
+
+		switch {
+		case value > vital.poor:
+			rating = "poor"
+		case value > vital.good:
+			rating = "needs improvement"
+		}
+
+		parts = append(parts, fmt.Sprintf("%s %s%s (%s)", vital.name, formatVital(value), vital.unit, rating))
+	}
+
+	if len(parts) > 0 {
+		log.Printf("Web Vitals %s: %s\n", webVitalStat, strings.Join(parts, ", "))
+	}
+}
+
+// formatVital formats the value of a Web Vital with up to 2 decimals.
+func formatVital(v float64) string {
+	return strconv.FormatFloat(math.Round(v*100)/100, 'f', -1, 64)
+}
@@ -0,0 +1,111 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package plugin
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/go-vela/vela-k6/models"
+)
+
+func TestCheckBrowser(t *testing.T) {
+	assert.NoError(t, (&pluginType{config: config{Browser: true, ScreenshotsPath: "/ws/screenshots"}}).checkBrowser())
+	assert.NoError(t, (&pluginType{config: config{BrowserHeadless: true}}).checkBrowser())
+
+	err := (&pluginType{config: config{ScreenshotsPath: "/ws/screenshots", BrowserTimeout: duration(time.Minute)}}).checkBrowser()
+	assert.EqualError(t, err, "'browser_timeout' requires 'browser'\n'screenshots_path' requires 'browser'")
+}
+
+func TestBrowserEnv(t *testing.T) {
+	assert.Nil(t, (&pluginType{config: config{BrowserHeadless: true}}).browserEnv())
+
+	p := &pluginType{config: config{
+		Browser:               true,
+		BrowserArgs:           []string{"no-sandbox", "window-size=1280,720"},
+		BrowserTimeout:        duration(time.Minute),
+		BrowserExecutablePath: "/usr/bin/chromium",
+	}}
+
+	assert.Equal(t, map[string]string{
+		"K6_BROWSER_HEADLESS":        "false",
+		"K6_BROWSER_ARGS":            "no-sandbox,window-size=1280,720",
+		"K6_BROWSER_TIMEOUT":         "1m0s",
+		"K6_BROWSER_EXECUTABLE_PATH": "/usr/bin/chromium",
+	}, p.browserEnv())
+}
+
+func TestFindChromium(t *testing.T) {
+	lookPath := func(file string) (string, error) {
+		if file == "google-chrome" {
+			return "/usr/bin/google-chrome", nil
+		}
+
+		return "", errors.New("not found")
+	}
+
+	path, err := (&pluginType{lookPath: lookPath}).findChromium()
+	require.NoError(t, err)
+	assert.Equal(t, "/usr/bin/google-chrome", path)
+
+	path, err = (&pluginType{config: config{BrowserExecutablePath: "/opt/chromium/chrome"}, lookPath: lookPath}).findChromium()
+	require.NoError(t, err)
+	assert.Equal(t, "/opt/chromium/chrome", path)
+
+	_, err = (&pluginType{lookPath: func(string) (string, error) { return "", errors.New("not found") }}).findChromium()
+	assert.ErrorContains(t, err, "'browser' requires Chromium, but none of chromium, chromium-browser, google-chrome, google-chrome-stable is on the PATH")
+}
+
+func TestCollectScreenshots(t *testing.T) {
+	root, err := filepath.EvalSymlinks(t.TempDir())
+	require.NoError(t, err)
+
+	t.Chdir(root)
+
+	old := filepath.Join(root, "old.png")
+	require.NoError(t, os.WriteFile(old, nil, 0600))
+	require.NoError(t, os.Chtimes(old, time.Now().Add(-time.Hour), time.Now().Add(-time.Hour)))
+
+	started := time.Now().Add(-time.Minute)
+
+	for _, name := range []string{"home.png", "pages/checkout.JPEG", "notes.txt", ".git/logo.png", "node_modules/pkg/icon.png"} {
+		require.NoError(t, os.MkdirAll(filepath.Dir(name), 0750))
+		require.NoError(t, os.WriteFile(name, nil, 0600))
+	}
+
+	dir := filepath.Join(root, "artifacts", "screenshots")
+
+	count, err := collectScreenshots(dir, started)
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+	assert.FileExists(t, filepath.Join(dir, "home.png"))
+	assert.FileExists(t, filepath.Join(dir, "pages", "checkout.JPEG"))
+	assert.NoFileExists(t, "home.png")
+	assert.FileExists(t, "old.png")
+	assert.FileExists(t, "notes.txt")
+	assert.FileExists(t, ".git/logo.png")
+	assert.FileExists(t, "node_modules/pkg/icon.png")
+
+	count, err = collectScreenshots(dir, started)
+	require.NoError(t, err)
+	assert.Zero(t, count)
+}
+
+func TestLogWebVitals(t *testing.T) {
+	buf := captureLog(t)
+
+	logWebVitals(nil)
+	logWebVitals(&models.Summary{Metrics: map[string]models.Metric{
+		"browser_web_vital_lcp": {Values: map[string]float64{"p(75)": 2812.456}},
+		"browser_web_vital_fcp": {Values: map[string]float64{"p(75)": 3200}},
+		"browser_web_vital_cls": {Values: map[string]float64{"p(75)": 0.05}},
+	}})
+
+	assert.Equal(t, "Web Vitals p(75): LCP 2812.46ms (needs improvement), FCP 3200ms (poor), CLS 0.05 (good)\n", buf.String())
+}
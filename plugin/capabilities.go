@@ -0,0 +1,173 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package plugin
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+
+	"github.com/go-vela/vela-k6/models"
+)
+
+// builtinOutput is a k6 output that needs no extension, with the k6
+// versions it is available in.
+type builtinOutput struct {
+	since   string
+	removed string
+}
+
+// builtinOutputs are the outputs built into k6, by name.
+var builtinOutputs = map[string]builtinOutput{
+	"json":                       {since: "v0.0.0"},
+	"cloud":                      {since: "v0.0.0"},
+	"influxdb":                   {since: "v0.0.0"},
+	"csv":                        {since: "v0.26.0"},
+	"statsd":                     {since: "v0.0.0", removed: "v0.55.0"},
+	"experimental-prometheus-rw": {since: "v0.42.0"},
+	"web-dashboard":              {since: "v0.49.0"},
+	"experimental-opentelemetry": {since: "v0.53.0"},
+}
+
+// k6Requirement is a k6 feature used by the configuration, with the
+// first k6 version supporting it.
+type k6Requirement struct {
+	feature string
+	since   string
+}
+
+// validateSemver checks that a string parameter is a semantic version.
+func validateSemver(v any) error {
+	if _, err := semver.NewVersion(v.(string)); err != nil {
+		return fmt.Errorf("%q is not a semantic version, such as v1.0.0", v.(string))
+	}
+
+	return nil
+}
+
+// CheckK6 runs `k6 version` and checks that the k6 binary supports the
+// configuration before the script is inspected: its version must be at
+// least 'min_k6_version' and the first version supporting the features
+// the configuration uses, every output must be built in or provided by
+// an extension, every extension in 'k6_extensions' must be built in, and
+// Chromium must be available in browser mode.
+// An error wrapping ErrInvalidConfig and listing every unsupported
+// feature is returned otherwise.
+func (p *pluginType) CheckK6() error {
+	k6, err := p.K6Version()
+	if err != nil {
+		return fmt.Errorf("probe k6: %w", err)
+	}
+
+	p.k6Version = k6
+
+	names := make([]string, 0, len(k6.Extensions))
+	for _, ext := range k6.Extensions {
+		names = append(names, ext.Name)
+	}
+
+	if len(names) > 0 {
+		log.Printf("Using %s %s with extensions: %s\n", p.k6Binary(), k6.Version, strings.Join(names, ", "))
+	} else {
+		log.Printf("Using %s %s\n", p.k6Binary(), k6.Version)
+	}
+
+	version, err := semver.NewVersion(k6.Version)
+	if err != nil {
+		return fmt.Errorf("parse k6 version %q: %w", k6.Version, err)
+	}
+
+	var errs []error
+
+	for _, req := range p.k6Requirements() {
+		if version.LessThan(semver.MustParse(req.since)) {
+			errs = append(errs, fmt.Errorf("%s requires k6 %s or later", req.feature, req.since))
+		}
+	}
+
+	for _, output := range p.config.Outputs {
+		if err := p.checkOutput(version, output); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	for _, name := range p.config.K6Extensions {
+		if !slices.ContainsFunc(k6.Extensions, func(ext models.K6Extension) bool { return ext.Name == name || ext.Module == name }) {
+			errs = append(errs, fmt.Errorf("extension %q from 'k6_extensions' is not built into the binary", name))
+		}
+	}
+
+	if p.config.Browser {
+		chromium, err := p.findChromium()
+		if err != nil {
+			errs = append(errs, err)
+		} else {
+			log.Printf("Using browser %s\n", chromium)
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("%w: %s %s doesn't support the configuration: %w", ErrInvalidConfig, p.k6Binary(), k6.Version, errors.Join(errs...))
+	}
+
+	return nil
+}
+
+// k6Requirements returns the k6 features used by the configuration that
+// are not supported by every k6 version.
+func (p *pluginType) k6Requirements() []k6Requirement {
+	var reqs []k6Requirement
+
+	if p.config.MinK6Version != "" {
+		reqs = append(reqs, k6Requirement{feature: "'min_k6_version'", since: p.config.MinK6Version})
+	}
+
+	switch {
+	case p.config.CompatibilityMode == "experimental_enhanced":
+		reqs = append(reqs, k6Requirement{feature: "'compatibility_mode' experimental_enhanced (TypeScript)", since: "v0.52.0"})
+	case isTypeScript(p.config.ScriptPath) && p.config.CompatibilityMode == "":
+		// older k6 versions run TypeScript in experimental_enhanced mode
+		reqs = append(reqs, k6Requirement{feature: "running TypeScript", since: "v0.52.0"})
+	case isTypeScript(p.config.ScriptPath):
+		reqs = append(reqs, k6Requirement{feature: "running TypeScript without 'compatibility_mode' experimental_enhanced", since: typeScriptVersion})
+	}
+
+	if p.config.Instances > 1 {
+		reqs = append(reqs, k6Requirement{feature: "'instances' (execution segments)", since: "v0.27.0"})
+	}
+
+	if p.config.Browser {
+		reqs = append(reqs, k6Requirement{feature: "'browser' (k6/browser module)", since: "v0.52.0"})
+	}
+
+	return reqs
+}
+
+// checkOutput checks that a k6 output is built into version, or provided
+// by an output extension of the probed binary.
+func (p *pluginType) checkOutput(version *semver.Version, output string) error {
+	name, _, _ := strings.Cut(output, "=")
+
+	for _, ext := range p.k6Version.Extensions {
+		if ext.Type == "output" && ext.Name == name {
+			return nil
+		}
+	}
+
+	builtin, ok := builtinOutputs[name]
+
+	switch {
+	case !ok:
+		return fmt.Errorf("output %q is not built into k6 and no extension provides it", name)
+	case version.LessThan(semver.MustParse(builtin.since)):
+		return fmt.Errorf("output %q requires k6 %s or later", name, builtin.since)
+	case builtin.removed != "" && !version.LessThan(semver.MustParse(builtin.removed)):
+		return fmt.Errorf("output %q was removed in k6 %s and no extension provides it", name, builtin.removed)
+	}
+
+	return nil
+}
@@ -0,0 +1,120 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package plugin
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/go-vela/vela-k6/plugin/mock"
+)
+
+func TestCheckK6(t *testing.T) {
+	const oldK6 = "k6 v0.48.0 (2023-12-04T11:29:08+0000/v0.48.0-0-g8a7e1c8, go1.21.4, linux/amd64)\n"
+
+	t.Run("Supported configuration", func(t *testing.T) {
+		t.Parallel()
+
+		p := &pluginType{
+			config: config{
+				MinK6Version:      "v0.52.0",
+				CompatibilityMode: "experimental_enhanced",
+				Instances:         2,
+				Outputs:           []string{"csv=results.csv", "web-dashboard", "xk6-influxdb=http://localhost:8086"},
+			},
+			buildCommand: mock.CommandBuilderWithOutput(k6VersionOutput, nil),
+		}
+
+		require.NoError(t, p.CheckK6())
+		assert.Equal(t, "v1.0.0", p.k6Version.Version)
+	})
+	t.Run("Older than min_k6_version", func(t *testing.T) {
+		t.Parallel()
+
+		p := &pluginType{
+			config:       config{MinK6Version: "v1.1.0"},
+			buildCommand: mock.CommandBuilderWithOutput(k6VersionOutput, nil),
+		}
+
+		err := p.CheckK6()
+		require.ErrorIs(t, err, ErrInvalidConfig)
+		assert.ErrorContains(t, err, "k6 v1.0.0 doesn't support the configuration: 'min_k6_version' requires k6 v1.1.0 or later")
+	})
+	t.Run("Unsupported features", func(t *testing.T) {
+		t.Parallel()
+
+		p := &pluginType{
+			config: config{
+				CompatibilityMode: "experimental_enhanced",
+				Outputs:           []string{"csv", "experimental-opentelemetry", "xk6-influxdb"},
+			},
+			buildCommand: mock.CommandBuilderWithOutput(oldK6, nil),
+		}
+
+		err := p.CheckK6()
+		require.ErrorIs(t, err, ErrInvalidConfig)
+		assert.ErrorContains(t, err, "'compatibility_mode' experimental_enhanced (TypeScript) requires k6 v0.52.0 or later")
+		assert.ErrorContains(t, err, `output "experimental-opentelemetry" requires k6 v0.53.0 or later`)
+		assert.ErrorContains(t, err, `output "xk6-influxdb" is not built into k6 and no extension provides it`)
+		assert.NotContains(t, err.Error(), `"csv"`)
+	})
+	t.Run("TypeScript script", func(t *testing.T) {
+		t.Parallel()
+
+		p := &pluginType{
+			config:       config{ScriptPath: "./test/script.ts"},
+			buildCommand: mock.CommandBuilderWithOutput(oldK6, nil),
+		}
+
+		assert.ErrorContains(t, p.CheckK6(), "running TypeScript requires k6 v0.52.0 or later")
+
+		p.config.CompatibilityMode = "base"
+		assert.ErrorContains(t, p.CheckK6(), "running TypeScript without 'compatibility_mode' experimental_enhanced requires k6 v0.57.0 or later")
+	})
+	t.Run("Extensions", func(t *testing.T) {
+		t.Parallel()
+
+		p := &pluginType{
+			config:       config{K6Extensions: []string{"k6/x/sql", "github.com/grafana/xk6-output-influxdb", "k6/x/kafka"}},
+			buildCommand: mock.CommandBuilderWithOutput(k6VersionOutput, nil),
+		}
+
+		err := p.CheckK6()
+		require.ErrorIs(t, err, ErrInvalidConfig)
+		assert.EqualError(t, err, `invalid configuration: k6 v1.0.0 doesn't support the configuration: extension "k6/x/kafka" from 'k6_extensions' is not built into the binary`)
+	})
+	t.Run("Browser", func(t *testing.T) {
+		t.Parallel()
+
+		p := &pluginType{
+			config:       config{Browser: true},
+			buildCommand: mock.CommandBuilderWithOutput(k6VersionOutput, nil),
+			lookPath:     func(string) (string, error) { return "/usr/bin/chromium", nil },
+		}
+
+		require.NoError(t, p.CheckK6())
+
+		p.lookPath = func(string) (string, error) { return "", errors.New("not found") }
+		assert.ErrorContains(t, p.CheckK6(), "'browser' requires Chromium")
+	})
+	t.Run("Removed output", func(t *testing.T) {
+		t.Parallel()
+
+		p := &pluginType{
+			config:       config{Outputs: []string{"statsd"}},
+			buildCommand: mock.CommandBuilderWithOutput(k6VersionOutput, nil),
+		}
+
+		assert.ErrorContains(t, p.CheckK6(), `output "statsd" was removed in k6 v0.55.0`)
+	})
+	t.Run("Probe error", func(t *testing.T) {
+		t.Parallel()
+
+		p := &pluginType{buildCommand: mock.CommandBuilderWithError(errors.New("exit status 1"), nil, nil, nil)}
+
+		assert.ErrorContains(t, p.CheckK6(), "probe k6: k6 version: exit status 1")
+	})
+}
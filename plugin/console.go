@@ -0,0 +1,187 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package plugin
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// Accepted values of the 'console_log_mode' parameter.
+const (
+	consoleLogModeCombined = "combined"
+	consoleLogModeSeparate = "separate"
+)
+
+// consoleLogModes are the accepted values of 'console_log_mode'.
+var consoleLogModes = []string{consoleLogModeCombined, consoleLogModeSeparate}
+
+// Names of the k6 output streams, used as line markers in the combined
+// console log and in the names of the separate console log files.
+const (
+	streamStdout = "stdout"
+	streamStderr = "stderr"
+)
+
+// consoleLog receives the output of the k6 run. Every line is written
+// raw to the console log files, if any. k6 JSON log entries are counted
+// and re-rendered, and the lines are either logged to the Vela log as they
+// are read or kept in a tail buffer that is logged when the run ends.
+type consoleLog struct {
+	mu       sync.Mutex
+	combined bool
+	writers  map[string]io.Writer
+	files    []*os.File
+	tailSize int
+	tail     []string
+	stats    k6LogStats
+	redact   *redactor
+}
+
+// newConsoleLog creates the console log files set in 'console_log_path'.
+// The last 'log_tail_lines' lines are kept for the Vela log when
+// 'log_progress' is enabled.
+func (p *pluginType) newConsoleLog() (*consoleLog, error) {
+	c := &consoleLog{
+		combined: p.config.ConsoleLogMode != consoleLogModeSeparate,
+		writers:  map[string]io.Writer{},
+		redact:   p.newRedactor(),
+	}
+
+	if p.config.LogProgress {
+		c.tailSize = p.config.LogTailLines
+	}
+
+	paths := p.consoleLogPaths()
+	if len(paths) == 0 {
+		return c, nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(p.config.ConsoleLogPath), os.FileMode(0755)); err != nil {
+		return nil, fmt.Errorf("create console log directory: %w", err)
+	}
+
+	opened := map[string]*os.File{}
+
+	for _, stream := range []string{streamStdout, streamStderr} {
+		f, ok := opened[paths[stream]]
+		if !ok {
+			var err error
+
+			f, err = os.Create(paths[stream]) //nolint:gosec // path is validated and confined to the workspace
+			if err != nil {
+				return nil, errors.Join(fmt.Errorf("create console log: %w", err), c.Close())
+			}
+
+			opened[paths[stream]] = f
+			c.files = append(c.files, f)
+		}
+
+		c.writers[stream] = f
+	}
+
+	return c, nil
+}
+
+// consoleLogPaths returns the console log file of each stream, or nil if
+// 'console_log_path' is not set. Both streams share the file in combined
+// mode. In separate mode, the stream name is added before the extension.
+func (p *pluginType) consoleLogPaths() map[string]string {
+	path := p.config.ConsoleLogPath
+	if path == "" {
+		return nil
+	}
+
+	if p.config.ConsoleLogMode != consoleLogModeSeparate {
+		return map[string]string{streamStdout: path, streamStderr: path}
+	}
+
+	ext := filepath.Ext(path)
+	base := strings.TrimSuffix(path, ext)
+
+	return map[string]string{
+		streamStdout: base + "." + streamStdout + ext,
+		streamStderr: base + "." + streamStderr + ext,
+	}
+}
+
+// readLines reads each line of the stream from pipe until it is closed.
+// Lines are written with prefix, which tells apart the k6 instances of a
+// distributed run. Done() is called on wg once the pipe is closed.
+func (c *consoleLog) readLines(stream, prefix string, pipe io.Reader, wg *sync.WaitGroup) {
+	defer wg.Done()
+
+	scanner := bufio.NewScanner(pipe)
+	for scanner.Scan() {
+		c.writeLine(stream, prefix, scanner.Text())
+	}
+}
+
+// writeLine writes a single line of the stream, with the secrets masked,
+// to its console log file and to the Vela log or the tail buffer.
+func (c *consoleLog) writeLine(stream, prefix, line string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	line = c.redact.redact(line)
+
+	if w := c.writers[stream]; w != nil {
+		if c.combined {
+			fmt.Fprintf(w, "[%s] %s%s\n", stream, prefix, line)
+		} else {
+			fmt.Fprintln(w, prefix+line)
+		}
+	}
+
+	if entry, ok := parseK6LogLine(line); ok {
+		c.stats.add(entry)
+		line = entry.String()
+	}
+
+	line = prefix + line
+
+	if c.tailSize <= 0 {
+		log.Println(line)
+		return
+	}
+
+	c.tail = append(c.tail, line)
+	if len(c.tail) > c.tailSize {
+		c.tail = c.tail[len(c.tail)-c.tailSize:]
+	}
+}
+
+// Close logs the tail buffer, if any, and closes the console log files.
+func (c *consoleLog) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(c.tail) > 0 {
+		log.Printf("Last %d lines of k6 output:\n", len(c.tail))
+
+		for _, line := range c.tail {
+			log.Println(line)
+		}
+
+		c.tail = nil
+	}
+
+	var errs []error
+
+	for _, f := range c.files {
+		if err := f.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("close console log: %w", err))
+		}
+	}
+
+	c.files = nil
+
+	return errors.Join(errs...)
+}
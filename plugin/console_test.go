@@ -0,0 +1,111 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package plugin
+
+import (
+	"bytes"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// captureLog redirects the standard logger to a buffer for the duration
+// of the test.
+func captureLog(t *testing.T) *bytes.Buffer {
+	t.Helper()
+
+	var buf bytes.Buffer
+
+	prevOut, prevFlags := log.Writer(), log.Flags()
+	log.SetOutput(&buf)
+	log.SetFlags(0)
+
+	t.Cleanup(func() {
+		log.SetOutput(prevOut)
+		log.SetFlags(prevFlags)
+	})
+
+	return &buf
+}
+
+// runConsoleLog feeds stdout and stderr through a new console log and
+// closes it.
+func runConsoleLog(t *testing.T, p *pluginType, stdout, stderr string) {
+	t.Helper()
+
+	c, err := p.newConsoleLog()
+	require.NoError(t, err)
+
+	wg := sync.WaitGroup{}
+	wg.Add(1)
+	c.readLines(streamStdout, "", strings.NewReader(stdout), &wg)
+	wg.Add(1)
+	c.readLines(streamStderr, "", strings.NewReader(stderr), &wg)
+
+	require.NoError(t, c.Close())
+}
+
+func TestConsoleLog(t *testing.T) {
+	t.Run("Combined", func(t *testing.T) {
+		buf := captureLog(t)
+		path := filepath.Join(t.TempDir(), "k6", "console.log")
+		p := &pluginType{config: config{ConsoleLogPath: path, ConsoleLogMode: consoleLogModeCombined}}
+
+		runConsoleLog(t, p, "checks...: 100%\n", "level=warning msg=slow\n")
+
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		assert.Equal(t, "[stdout] checks...: 100%\n[stderr] level=warning msg=slow\n", string(data))
+		assert.Equal(t, "checks...: 100%\nlevel=warning msg=slow\n", buf.String())
+	})
+	t.Run("Separate", func(t *testing.T) {
+		captureLog(t)
+		dir := t.TempDir()
+		p := &pluginType{config: config{ConsoleLogPath: filepath.Join(dir, "console.log"), ConsoleLogMode: consoleLogModeSeparate}}
+
+		runConsoleLog(t, p, "out 1\nout 2\n", "err 1\n")
+
+		stdout, err := os.ReadFile(filepath.Join(dir, "console.stdout.log"))
+		require.NoError(t, err)
+		assert.Equal(t, "out 1\nout 2\n", string(stdout))
+
+		stderr, err := os.ReadFile(filepath.Join(dir, "console.stderr.log"))
+		require.NoError(t, err)
+		assert.Equal(t, "err 1\n", string(stderr))
+		assert.NoFileExists(t, filepath.Join(dir, "console.log"))
+	})
+	t.Run("Tail", func(t *testing.T) {
+		buf := captureLog(t)
+		p := &pluginType{config: config{LogProgress: true, LogTailLines: 2}}
+
+		runConsoleLog(t, p, "line 1\nline 2\nline 3\n", "")
+
+		assert.Equal(t, "Last 2 lines of k6 output:\nline 2\nline 3\n", buf.String())
+	})
+	t.Run("Secrets", func(t *testing.T) {
+		buf := captureLog(t)
+		path := filepath.Join(t.TempDir(), "console.log")
+		p := &pluginType{config: config{ConsoleLogPath: path, Env: map[string]string{"API_TOKEN": "s3cr3t-value"}}}
+
+		runConsoleLog(t, p, "token s3cr3t-value\n", "Authorization: Bearer eyJhbGciOi.x\n")
+
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		assert.Equal(t, "[stdout] token ***\n[stderr] Authorization: Bearer ***\n", string(data))
+		assert.Equal(t, "token ***\nAuthorization: Bearer ***\n", buf.String())
+	})
+	t.Run("Tail Without Progress", func(t *testing.T) {
+		buf := captureLog(t)
+		p := &pluginType{config: config{LogTailLines: 2}}
+
+		runConsoleLog(t, p, "line 1\nline 2\nline 3\n", "")
+
+		assert.Equal(t, "line 1\nline 2\nline 3\n", buf.String())
+	})
+}
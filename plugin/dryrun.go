@@ -0,0 +1,146 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package plugin
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+)
+
+// shellSafePattern matches arguments that don't need quoting in a shell.
+var shellSafePattern = regexp.MustCompile(`^[a-zA-Z0-9_./=:,+@%-]+$`)
+
+// DryRunEnabled returns true if the 'dry_run' parameter is set.
+func (p *pluginType) DryRunEnabled() bool {
+	return p.config.DryRun
+}
+
+// DryRun validates the configured paths and prints the effective
+// configuration and the commands the plugin would execute, with secrets
+// masked, without running the setup script or k6.
+func (p *pluginType) DryRun() error {
+	if err := p.validatePaths(); err != nil {
+		return err
+	}
+
+	// HTML escaping is disabled so secrets containing '&' are still masked
+	var effective strings.Builder
+
+	enc := json.NewEncoder(&effective)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+
+	if err := enc.Encode(p.config); err != nil {
+		return fmt.Errorf("encode configuration: %w", err)
+	}
+
+	r := p.newRedactor()
+
+	log.Println("Dry run enabled, the setup script and k6 will not be executed.")
+	log.Printf("Effective configuration:\n%s\n", r.redact(strings.TrimSpace(effective.String())))
+
+	if p.config.SetupScriptPath != "" {
+		log.Printf("Setup command: %s\n", r.redact(shellJoin(p.config.SetupScriptPath)))
+	} else {
+		log.Println("No setup script specified.")
+	}
+
+	if env := p.browserEnv(); env != nil {
+		vars := make([]string, 0, len(env))
+		for _, key := range sortedKeys(env) {
+			vars = append(vars, fmt.Sprintf("%s=%s", key, env[key]))
+		}
+
+		log.Printf("k6 browser environment: %s\n", r.redact(shellJoin(vars[0], vars[1:]...)))
+	}
+
+	if p.config.ArchiveOutputPath != "" {
+		log.Printf("k6 archive command: %s\n", r.redact(shellJoin(p.k6Binary(), p.k6ArchiveArgs()...)))
+	}
+
+	if cfg := p.k6Config(); cfg != nil {
+		generated, err := json.MarshalIndent(cfg, "", "  ")
+		if err != nil {
+			return fmt.Errorf("encode k6 config: %w", err)
+		}
+
+		log.Printf("Generated k6 config %s:\n%s\n", p.k6ConfigPath(), r.redact(string(generated)))
+	}
+
+	if path := p.thresholdsModulePath(); path != "" {
+		module, err := p.thresholdsModule()
+		if err != nil {
+			return fmt.Errorf("encode thresholds module: %w", err)
+		}
+
+		log.Printf("Generated thresholds module %s:\n%s", path, r.redact(module))
+	}
+
+	if p.config.Instances > 1 {
+		for i := range p.config.Instances {
+			log.Printf("k6 command (instance %d): %s\n", i+1, r.redact(shellJoin(p.k6Binary(), p.instanceRunArgs(i)...)))
+		}
+	} else {
+		log.Printf("k6 command: %s\n", r.redact(shellJoin(p.k6Binary(), p.k6RunArgs()...)))
+	}
+
+	return nil
+}
+
+// validatePaths verifies that every file the plugin reads exists, and
+// returns all missing files at once.
+func (p *pluginType) validatePaths() error {
+	paths := map[string]string{
+		"script_path":       p.config.ScriptPath,
+		"setup_script_path": p.config.SetupScriptPath,
+		"k6_config_path":    p.config.K6ConfigPath,
+	}
+
+	if p.config.Notify != nil {
+		paths["notify.baseline_path"] = p.config.Notify.BaselinePath
+	}
+
+	var errs []error
+
+	for _, name := range sortedKeys(paths) {
+		if paths[name] == "" {
+			continue
+		}
+
+		if err := p.verifyFileExists(paths[name]); err != nil {
+			errs = append(errs, fmt.Errorf("'%s' %s: %w", name, paths[name], err))
+		}
+	}
+
+	if isArchive(p.config.ScriptPath) && len(errs) == 0 {
+		if err := validateArchive(p.config.ScriptPath); err != nil {
+			errs = append(errs, fmt.Errorf("'script_path': %w", err))
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("%w: %w", ErrInvalidConfig, errors.Join(errs...))
+	}
+
+	return nil
+}
+
+// shellJoin returns name and args as a single command line, quoting
+// arguments that contain characters special to the shell.
+func shellJoin(name string, args ...string) string {
+	parts := make([]string, 0, len(args)+1)
+
+	for _, arg := range append([]string{name}, args...) {
+		if shellSafePattern.MatchString(arg) {
+			parts = append(parts, arg)
+		} else {
+			parts = append(parts, "'"+strings.ReplaceAll(arg, "'", `'\''`)+"'")
+		}
+	}
+
+	return strings.Join(parts, " ")
+}
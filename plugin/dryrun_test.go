@@ -0,0 +1,73 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package plugin
+
+import (
+	"bytes"
+	"errors"
+	"log"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/go-vela/vela-k6/models"
+	"github.com/go-vela/vela-k6/plugin/mock"
+)
+
+func TestDryRun(t *testing.T) {
+	t.Run("Prints Plan", func(t *testing.T) {
+		var buf bytes.Buffer
+
+		prevOut := log.Writer()
+		log.SetOutput(&buf)
+
+		defer func() {
+			log.SetOutput(prevOut)
+		}()
+
+		p := &pluginType{
+			config: config{
+				ScriptPath:      "./test/script.js",
+				OutputPath:      "./output.json",
+				SetupScriptPath: "./test/setup.sh",
+				DryRun:          true,
+				Notify: &models.NotifyConfig{
+					Webhooks: []models.Webhook{{URL: "https://hooks.example.com/T000/B000?token=abc&x=1", Format: formatSlack}},
+				},
+			},
+			buildCommand:     mock.CommandBuilderWithError(errors.New("must not run"), nil, nil, nil),
+			verifyFileExists: func(string) error { return nil },
+		}
+
+		assert.True(t, p.DryRunEnabled())
+		assert.NoError(t, p.DryRun())
+
+		out := buf.String()
+		assert.Contains(t, out, `"script_path": "./test/script.js"`)
+		assert.Contains(t, out, `"url": "***"`)
+		assert.NotContains(t, out, "token=abc")
+		assert.Contains(t, out, "Setup command: ./test/setup.sh")
+		assert.Contains(t, out, "k6 command: k6 run -q --log-format=json --address=localhost:6565 --out json=./output.json --summary-export=")
+	})
+	t.Run("Reports All Missing Files", func(t *testing.T) {
+		p := &pluginType{
+			config: config{
+				ScriptPath:      "./test/script.js",
+				SetupScriptPath: "./test/setup.sh",
+				Notify:          &models.NotifyConfig{BaselinePath: "./baseline.json"},
+			},
+			verifyFileExists: func(string) error { return errors.New("no such file") },
+		}
+
+		err := p.DryRun()
+		assert.ErrorIs(t, err, ErrInvalidConfig)
+		assert.ErrorContains(t, err, "'script_path' ./test/script.js")
+		assert.ErrorContains(t, err, "'setup_script_path' ./test/setup.sh")
+		assert.ErrorContains(t, err, "'notify.baseline_path' ./baseline.json")
+	})
+}
+
+func TestShellJoin(t *testing.T) {
+	assert.Equal(t, "k6 run -q ./test/script.js", shellJoin("k6", "run", "-q", "./test/script.js"))
+	assert.Equal(t, `k6 run 'my script.js' 'it'\''s.js'`, shellJoin("k6", "run", "my script.js", "it's.js"))
+}
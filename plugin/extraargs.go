@@ -0,0 +1,154 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package plugin
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// allowedRunFlags are the `k6 run` flags accepted in 'extra_args', mapped
+// to whether the flag takes a value.
+var allowedRunFlags = map[string]bool{
+	"--batch":                    true,
+	"--batch-per-host":           true,
+	"--blacklist-ip":             true,
+	"--block-hostnames":          true,
+	"--discard-response-bodies":  false,
+	"--dns":                      true,
+	"--duration":                 true,
+	"--http-debug":               false,
+	"--include-system-env-vars":  false,
+	"--insecure-skip-tls-verify": false,
+	"--iterations":               true,
+	"--local-ips":                true,
+	"--max-redirects":            true,
+	"--min-iteration-duration":   true,
+	"--no-connection-reuse":      false,
+	"--no-setup":                 false,
+	"--no-teardown":              false,
+	"--no-thresholds":            false,
+	"--no-usage-report":          false,
+	"--no-vu-connection-reuse":   false,
+	"--rps":                      true,
+	"--setup-timeout":            true,
+	"--stage":                    true,
+	"--system-tags":              true,
+	"--teardown-timeout":         true,
+	"--throw":                    false,
+	"--user-agent":               true,
+	"--vus":                      true,
+}
+
+// shortRunFlags maps the short `k6 run` flags to their long form.
+var shortRunFlags = map[string]string{
+	"-c": "--config",
+	"-d": "--duration",
+	"-e": "--env",
+	"-i": "--iterations",
+	"-o": "--out",
+	"-a": "--address",
+	"-q": "--quiet",
+	"-s": "--stage",
+	"-u": "--vus",
+	"-w": "--throw",
+}
+
+// managedRunFlags are the `k6 run` flags set by the plugin, mapped to the
+// parameter to use instead.
+var managedRunFlags = map[string]string{
+	"--address":                    "api_address",
+	"--compatibility-mode":         "compatibility_mode",
+	"--config":                     "k6_config_path",
+	"--env":                        "env",
+	"--execution-segment":          "instances",
+	"--execution-segment-sequence": "instances",
+	"--linger":                     "linger",
+	"--no-summary":                 "no_summary",
+	"--out":                        "output_path' or 'outputs",
+	"--quiet":                      "log_progress",
+	"--summary-export":             "output_path' with 'projektor_compat_mode",
+	"--summary-time-unit":          "summary_time_unit",
+	"--summary-trend-stats":        "summary_trend_stats",
+	"--tag":                        "tags",
+}
+
+// loadShapeFlags are the flags that set the load shape, which conflict
+// with a load profile.
+var loadShapeFlags = []string{"--vus", "--duration", "--stage", "--iterations"}
+
+// validateExtraArgs checks that every flag of 'extra_args' is an allowed
+// `k6 run` flag, and that flags taking a value have one.
+func validateExtraArgs(v any) error {
+	_, err := parseExtraArgs(v.([]string))
+	return err
+}
+
+// parseExtraArgs returns the long names of the flags in args. Flag values
+// may be given as --flag=value or as the next argument.
+func parseExtraArgs(args []string) ([]string, error) {
+	var (
+		flags []string
+		errs  []error
+	)
+
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if !strings.HasPrefix(arg, "-") {
+			errs = append(errs, fmt.Errorf("%q is not a flag", arg))
+			continue
+		}
+
+		name, _, hasValue := strings.Cut(arg, "=")
+		if long, ok := shortRunFlags[name]; ok {
+			name = long
+		}
+
+		if param, ok := managedRunFlags[name]; ok {
+			errs = append(errs, fmt.Errorf("%s is set by the plugin, use '%s' instead", name, param))
+			continue
+		}
+
+		takesValue, ok := allowedRunFlags[name]
+		if !ok {
+			errs = append(errs, fmt.Errorf("%s is not a supported k6 run flag", name))
+			continue
+		}
+
+		if takesValue && !hasValue {
+			if i+1 >= len(args) {
+				errs = append(errs, fmt.Errorf("%s requires a value", name))
+				continue
+			}
+
+			i++
+		}
+
+		flags = append(flags, name)
+	}
+
+	return flags, errors.Join(errs...)
+}
+
+// checkExtraArgs rejects 'extra_args' flags that conflict with the other
+// parameters.
+func (cfg *config) checkExtraArgs() error {
+	if cfg.Profile == "" {
+		return nil
+	}
+
+	flags, err := parseExtraArgs(cfg.ExtraArgs)
+	if err != nil {
+		return err
+	}
+
+	for _, flag := range flags {
+		if slices.Contains(loadShapeFlags, flag) {
+			return fmt.Errorf("%s conflicts with the load shape of 'profile' %q", flag, cfg.Profile)
+		}
+	}
+
+	return nil
+}
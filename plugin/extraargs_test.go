@@ -0,0 +1,39 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package plugin
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseExtraArgs(t *testing.T) {
+	flags, err := parseExtraArgs([]string{"--http-debug", "--batch=20", "--user-agent", "vela-k6", "-u", "5", "-w"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"--http-debug", "--batch", "--user-agent", "--vus", "--throw"}, flags)
+
+	for _, test := range []struct {
+		args     []string
+		expected string
+	}{
+		{[]string{"insecure"}, `"insecure" is not a flag`},
+		{[]string{"--out=csv=a.csv"}, "--out is set by the plugin, use 'output_path' or 'outputs' instead"},
+		{[]string{"-q"}, "--quiet is set by the plugin, use 'log_progress' instead"},
+		{[]string{"--config=k6.json"}, "--config is set by the plugin, use 'k6_config_path' instead"},
+		{[]string{"--summary-trend-stats=avg"}, "--summary-trend-stats is set by the plugin, use 'summary_trend_stats' instead"},
+		{[]string{"--linger"}, "--linger is set by the plugin, use 'linger' instead"},
+		{[]string{"--http-debug", "--batch"}, "--batch requires a value"},
+	} {
+		_, err := parseExtraArgs(test.args)
+		assert.EqualError(t, err, test.expected, test.args)
+	}
+}
+
+func TestCheckExtraArgs(t *testing.T) {
+	assert.NoError(t, (&config{ExtraArgs: []string{"--vus", "10"}}).checkExtraArgs())
+	assert.NoError(t, (&config{Profile: "smoke", ExtraArgs: []string{"--http-debug"}}).checkExtraArgs())
+	assert.EqualError(t, (&config{Profile: "smoke", ExtraArgs: []string{"-d", "5m"}}).checkExtraArgs(),
+		`--duration conflicts with the load shape of 'profile' "smoke"`)
+}
@@ -0,0 +1,77 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package plugin
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// paramFlag is the command-line flag of a parameter. Its raw value is
+// parsed like the value of the Vela parameter.
+type paramFlag struct {
+	param parameter
+	value *string
+}
+
+// String returns the raw value of the flag.
+func (f paramFlag) String() string {
+	if f.value == nil {
+		return ""
+	}
+
+	return *f.value
+}
+
+// Set stores the raw value of the flag.
+func (f paramFlag) Set(raw string) error {
+	*f.value = raw
+	return nil
+}
+
+// IsBoolFlag lets boolean parameters be set without a value, such as
+// --dry-run.
+func (f paramFlag) IsBoolFlag() bool {
+	return f.param.Type == typeBool
+}
+
+// flagName returns the command-line flag of the parameter, such as
+// "script-path" for 'script_path'.
+func (param parameter) flagName() string {
+	return strings.ReplaceAll(param.Name, "_", "-")
+}
+
+// RegisterFlags defines a flag on fs for every parameter, named after the
+// parameter with dashes, such as --script-path. The returned function
+// must be called once fs is parsed: it sets the PARAMETER_ environment
+// variable of every flag given on the command line, so flags take
+// precedence over the environment and the test plan, and are parsed and
+// validated by ConfigFromEnv like the Vela parameters.
+func RegisterFlags(fs *flag.FlagSet) func() error {
+	for _, param := range parameters {
+		// backquotes would name the flag argument in the usage
+		usage := strings.ReplaceAll(param.Description, "`", "")
+		if param.Default != "" {
+			usage += fmt.Sprintf(" (default %q)", param.Default)
+		}
+
+		fs.Var(paramFlag{param: param, value: new(string)}, param.flagName(), fmt.Sprintf("%s: %s", param.Type, usage))
+	}
+
+	return func() error {
+		var err error
+
+		fs.Visit(func(f *flag.Flag) {
+			pf, ok := f.Value.(paramFlag)
+			if !ok || err != nil {
+				return
+			}
+
+			err = os.Setenv(pf.param.envName(), *pf.value)
+		})
+
+		return err
+	}
+}
@@ -0,0 +1,46 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package plugin
+
+import (
+	"flag"
+	"io"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegisterFlags(t *testing.T) {
+	for _, param := range parameters {
+		t.Setenv(param.envName(), "")
+	}
+
+	t.Setenv("PARAMETER_OUTPUT_PATH", "env.json")
+
+	fs := flag.NewFlagSet("run", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+
+	apply := RegisterFlags(fs)
+	require.NoError(t, fs.Parse([]string{"--script-path", "./test/script.js", "--dry-run", "--output-path=flag.json", "--tags", "team=perf"}))
+	require.NoError(t, apply())
+
+	assert.Equal(t, "./test/script.js", os.Getenv("PARAMETER_SCRIPT_PATH"))
+	assert.Equal(t, "true", os.Getenv("PARAMETER_DRY_RUN"))
+	assert.Equal(t, "flag.json", os.Getenv("PARAMETER_OUTPUT_PATH"))
+	assert.Equal(t, "team=perf", os.Getenv("PARAMETER_TAGS"))
+	assert.Empty(t, os.Getenv("PARAMETER_LOG_PROGRESS"), "flags not given keep the environment")
+
+	assert.Len(t, flagNames(fs), len(parameters))
+	assert.Contains(t, fs.Lookup("thresholds-mode").Usage, `(default "merge")`)
+}
+
+// flagNames returns the names of the flags defined on fs.
+func flagNames(fs *flag.FlagSet) []string {
+	var names []string
+
+	fs.VisitAll(func(f *flag.Flag) { names = append(names, f.Name) })
+
+	return names
+}
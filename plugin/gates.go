@@ -0,0 +1,33 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package plugin
+
+import (
+	"fmt"
+	"log"
+)
+
+// checkGates evaluates the configured gates against the summary of the
+// run, and returns an error listing every gate that is not met.
+func (p *pluginType) checkGates() error {
+	if len(p.config.Gates) == 0 {
+		return nil
+	}
+
+	var failed []string
+
+	for _, gate := range p.config.Gates {
+		if ok, reason := gate.Evaluate(p.result.Summary); !ok {
+			failed = append(failed, reason)
+			log.Printf("Gate failed: %s\n", reason)
+		} else {
+			log.Printf("Gate passed: %s\n", gate)
+		}
+	}
+
+	if len(failed) > 0 {
+		return fmt.Errorf("%d of %d gates failed", len(failed), len(p.config.Gates))
+	}
+
+	return nil
+}
@@ -0,0 +1,37 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package plugin
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/go-vela/vela-k6/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckGates(t *testing.T) {
+	limit := func(v float64) *float64 { return &v }
+	summary := &models.Summary{Metrics: map[string]models.Metric{
+		"http_req_duration": {Values: map[string]float64{"p(95)": 250}},
+	}}
+
+	p := &pluginType{result: runResult{Summary: summary}}
+	assert.NoError(t, p.checkGates())
+
+	p.config.Gates = []models.Gate{
+		{Metric: "http_req_duration", Stat: "p(95)", Max: limit(300)},
+		{Metric: "http_req_duration", Stat: "p(95)", Max: limit(200)},
+	}
+	assert.NoError(t, (&pluginType{config: config{Gates: p.config.Gates[:1]}, result: p.result}).checkGates())
+	assert.EqualError(t, p.checkGates(), "1 of 2 gates failed")
+}
+
+func TestSetGates(t *testing.T) {
+	cfg := &config{}
+	assert.NoError(t, setGates(cfg, json.RawMessage(`[{"metric": "checks", "stat": "value", "min": 0.99}]`)))
+	assert.Len(t, cfg.Gates, 1)
+
+	assert.ErrorContains(t, setGates(cfg, json.RawMessage(`[{"metric": "checks", "stat": "value"}]`)), "must have a max or a min")
+	assert.ErrorContains(t, setGates(cfg, json.RawMessage(`[{"metric": "checks"}]`)), "must have a metric and a stat")
+}
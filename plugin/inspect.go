@@ -0,0 +1,120 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package plugin
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/go-vela/vela-k6/models"
+)
+
+// InspectScript runs `k6 inspect` on the script as a pre-flight check
+// before the setup script runs, with the same k6 config file and env as
+// `k6 run`. It logs the scenarios, executors and
+// thresholds derived from the script options, and returns an error
+// wrapping ErrInvalidConfig if the script does not compile or, when
+// 'require_thresholds' is enabled, defines no thresholds.
+func (p *pluginType) InspectScript() error {
+	err := p.verifyScript()
+	if err != nil {
+		return err
+	}
+
+	// the script is inspected with the config and env it runs with, as its
+	// options may depend on them
+	if err := p.writeK6ConfigFile(); err != nil {
+		return err
+	}
+
+	args := []string{"inspect", "--execution-requirements"}
+	args = append(args, p.compatibilityArgs()...)
+	args = append(args, p.configArgs()...)
+	cmd := p.buildCommand(p.k6Binary(), append(args, p.config.ScriptPath)...)
+
+	stdout, stderr, err := startCommand(cmd)
+	if err != nil {
+		return err
+	}
+
+	log.Println("Inspecting script...")
+
+	wg := sync.WaitGroup{}
+	wg.Add(1)
+
+	go readLinesFromPipe(stderr, &wg, p.newRedactor())
+
+	output, readErr := io.ReadAll(stdout)
+
+	wg.Wait()
+
+	if err := cmd.Wait(); err != nil {
+		return fmt.Errorf("%w: script %s failed to compile: %w", ErrInvalidConfig, p.config.ScriptPath, err)
+	}
+
+	if readErr != nil {
+		return fmt.Errorf("read k6 inspect output: %w", readErr)
+	}
+
+	result := &models.InspectResult{}
+	if err := json.Unmarshal(output, result); err != nil {
+		return fmt.Errorf("decode k6 inspect output: %w", err)
+	}
+
+	p.scriptOptions = result
+	logScriptOptions(result)
+
+	if p.config.RequireThresholds && len(result.Options.Thresholds) == 0 && len(p.config.Thresholds) == 0 {
+		return fmt.Errorf("%w: script %s defines no thresholds but 'require_thresholds' is enabled", ErrInvalidConfig, p.config.ScriptPath)
+	}
+
+	return nil
+}
+
+// logScriptOptions logs a summary of the inspected script options.
+func logScriptOptions(result *models.InspectResult) {
+	log.Printf("Script defines %d scenario(s) using up to %d VUs for up to %s\n",
+		len(result.Options.Scenarios), result.MaxVUs, result.TotalDuration)
+
+	for _, name := range sortedKeys(result.Options.Scenarios) {
+		scenario := result.Options.Scenarios[name]
+		if scenario.Exec != "" {
+			log.Printf("  scenario %q: executor %s, exec %s\n", name, scenario.Executor, scenario.Exec)
+		} else {
+			log.Printf("  scenario %q: executor %s\n", name, scenario.Executor)
+		}
+	}
+
+	if len(result.Options.Thresholds) == 0 {
+		log.Println("Script defines no thresholds")
+		return
+	}
+
+	log.Printf("Script defines thresholds on %d metric(s)\n", len(result.Options.Thresholds))
+
+	for _, metric := range sortedKeys(result.Options.Thresholds) {
+		expressions := make([]string, 0, len(result.Options.Thresholds[metric]))
+		for _, threshold := range result.Options.Thresholds[metric] {
+			expressions = append(expressions, threshold.Threshold)
+		}
+
+		log.Printf("  %s: %s\n", metric, strings.Join(expressions, ", "))
+	}
+}
+
+// sortedKeys returns the keys of m in ascending order.
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
@@ -0,0 +1,147 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package plugin
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/go-vela/vela-k6/models"
+	"github.com/go-vela/vela-k6/plugin/mock"
+)
+
+const inspectOutput = `{
+  "options": {
+    "scenarios": {
+      "browse": {"executor": "ramping-vus", "exec": "browse"},
+      "default": {"executor": "constant-vus"}
+    },
+    "thresholds": {
+      "http_req_duration": ["p(95)<500", {"threshold": "p(99)<1000", "abortOnFail": true}],
+      "http_req_failed": ["rate<0.01"]
+    }
+  },
+  "totalDuration": "5m30s",
+  "maxVUs": 50
+}`
+
+func TestInspectScript(t *testing.T) {
+	verifyFileExists := func(string) error { return nil }
+
+	t.Run("Successful Inspect", func(t *testing.T) {
+		t.Parallel()
+
+		p := &pluginType{
+			config:           config{ScriptPath: "./test/script.js", RequireThresholds: true},
+			buildCommand:     mock.CommandBuilderWithOutput(inspectOutput, nil),
+			verifyFileExists: verifyFileExists,
+		}
+
+		require.NoError(t, p.InspectScript())
+		assert.Equal(t, 50, p.scriptOptions.MaxVUs)
+		assert.Equal(t, "ramping-vus", p.scriptOptions.Options.Scenarios["browse"].Executor)
+		assert.Len(t, p.scriptOptions.Options.Thresholds["http_req_duration"], 2)
+	})
+	t.Run("Config And Env", func(t *testing.T) {
+		t.Parallel()
+
+		var inspectArgs []string
+
+		p := &pluginType{
+			config: config{
+				ScriptPath: "./test/script.js",
+				Env:        map[string]string{"BASE_URL": "https://example.com"},
+				Thresholds: map[string][]models.Threshold{"http_req_failed": {{Threshold: "rate<0.01"}}},
+			},
+			buildCommand: func(name string, args ...string) models.ShellCommand {
+				inspectArgs = args
+				return mock.CommandBuilderWithOutput(inspectOutput, nil)(name, args...)
+			},
+			verifyFileExists: verifyFileExists,
+			tempDir:          t.TempDir(),
+		}
+
+		require.NoError(t, p.InspectScript())
+		assert.Equal(t, []string{
+			"inspect", "--execution-requirements",
+			"--config", filepath.Join(p.tempDir, k6ConfigFile),
+			"-e", "BASE_URL=https://example.com",
+			"./test/script.js",
+		}, inspectArgs)
+		assert.FileExists(t, filepath.Join(p.tempDir, k6ConfigFile))
+	})
+	t.Run("Script file not present", func(t *testing.T) {
+		t.Parallel()
+
+		p := &pluginType{
+			config:           config{ScriptPath: "./test/doesnotexist.js"},
+			buildCommand:     mock.CommandBuilderWithOutput(inspectOutput, nil),
+			verifyFileExists: func(string) error { return errors.New("no such file") },
+		}
+
+		assert.ErrorContains(t, p.InspectScript(), "read script file at")
+	})
+	t.Run("Start error", func(t *testing.T) {
+		t.Parallel()
+
+		p := &pluginType{
+			config:           config{ScriptPath: "./test/script.js"},
+			buildCommand:     mock.CommandBuilderWithError(nil, nil, nil, errors.New("some error")),
+			verifyFileExists: verifyFileExists,
+		}
+
+		assert.ErrorContains(t, p.InspectScript(), "start command")
+	})
+	t.Run("Compile error", func(t *testing.T) {
+		t.Parallel()
+
+		p := &pluginType{
+			config:           config{ScriptPath: "./test/script.js"},
+			buildCommand:     mock.CommandBuilderWithOutput("", errors.New("exit status 107")),
+			verifyFileExists: verifyFileExists,
+		}
+
+		err := p.InspectScript()
+		assert.ErrorIs(t, err, ErrInvalidConfig)
+		assert.ErrorContains(t, err, "failed to compile")
+	})
+	t.Run("Invalid output", func(t *testing.T) {
+		t.Parallel()
+
+		p := &pluginType{
+			config:           config{ScriptPath: "./test/script.js"},
+			buildCommand:     mock.CommandBuilderWithOutput("not json", nil),
+			verifyFileExists: verifyFileExists,
+		}
+
+		assert.ErrorContains(t, p.InspectScript(), "decode k6 inspect output")
+	})
+	t.Run("Thresholds required", func(t *testing.T) {
+		t.Parallel()
+
+		p := &pluginType{
+			config:           config{ScriptPath: "./test/script.js", RequireThresholds: true},
+			buildCommand:     mock.CommandBuilderWithOutput(`{"options": {}, "maxVUs": 1}`, nil),
+			verifyFileExists: verifyFileExists,
+		}
+
+		err := p.InspectScript()
+		assert.ErrorIs(t, err, ErrInvalidConfig)
+		assert.ErrorContains(t, err, "defines no thresholds")
+	})
+	t.Run("Thresholds not required", func(t *testing.T) {
+		t.Parallel()
+
+		p := &pluginType{
+			config:           config{ScriptPath: "./test/script.js"},
+			buildCommand:     mock.CommandBuilderWithOutput(`{"options": {}, "maxVUs": 1}`, nil),
+			verifyFileExists: verifyFileExists,
+		}
+
+		assert.NoError(t, p.InspectScript())
+	})
+}
@@ -0,0 +1,297 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package plugin
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/go-vela/vela-k6/merge"
+	"github.com/go-vela/vela-k6/models"
+)
+
+// maxInstances is the largest accepted 'instances'.
+const maxInstances = 32
+
+// instanceOutputFile is the name of the k6 JSON output written to the temp
+// directory by each instance of a distributed run.
+const instanceOutputFile = "vela-k6-instance-%d.json"
+
+// validateInstances checks that 'instances' is between 1 and maxInstances.
+func validateInstances(v any) error {
+	if n := v.(int); n < 1 || n > maxInstances {
+		return fmt.Errorf("%d must be between 1 and %d", n, maxInstances)
+	}
+
+	return nil
+}
+
+// checkInstances rejects the parameters a distributed run does not
+// support: the plugin follows the metrics of a single k6 process during
+// the run, and each instance serves its REST API on the next port after
+// 'api_address'.
+func (p *pluginType) checkInstances() error {
+	if p.config.Instances <= 1 {
+		return nil
+	}
+
+	if p.followsMetrics() || p.config.Linger {
+		return errors.New("can't be combined with 'progress_interval', 'abort_conditions' or 'linger'")
+	}
+
+	_, port, _ := net.SplitHostPort(p.apiAddress())
+	if n, err := strconv.Atoi(port); err != nil || n+p.config.Instances-1 > 65535 {
+		return fmt.Errorf("'api_address' %q must have a numeric port leaving room for %d instances", p.apiAddress(), p.config.Instances)
+	}
+
+	return nil
+}
+
+// executionSegments returns the --execution-segment of each of n
+// instances, which split the test into equal parts, and the
+// --execution-segment-sequence they share.
+func executionSegments(n int) (segments []string, sequence string) {
+	fraction := func(i int) string {
+		switch i {
+		case 0:
+			return "0"
+		case n:
+			return "1"
+		default:
+			return fmt.Sprintf("%d/%d", i, n)
+		}
+	}
+
+	bounds := make([]string, n+1)
+	for i := range bounds {
+		bounds[i] = fraction(i)
+	}
+
+	for i := range n {
+		segments = append(segments, bounds[i]+":"+bounds[i+1])
+	}
+
+	return segments, strings.Join(bounds, ",")
+}
+
+// instanceAddress returns the address instance i serves its REST API on:
+// the port of 'api_address' plus i.
+func (p *pluginType) instanceAddress(i int) string {
+	host, port, _ := net.SplitHostPort(p.apiAddress())
+	n, _ := strconv.Atoi(port)
+
+	return net.JoinHostPort(host, strconv.Itoa(n+i))
+}
+
+// instanceOutputPath returns the k6 JSON output of instance i.
+func (p *pluginType) instanceOutputPath(i int) string {
+	return p.tempPath(fmt.Sprintf(instanceOutputFile, i+1))
+}
+
+// instanceRunArgs returns the arguments passed to k6 by instance i. Every
+// instance writes its own JSON output, which the plugin merges once they
+// exit, instead of the output file and the summary export.
+func (p *pluginType) instanceRunArgs(i int) []string {
+	segments, sequence := executionSegments(p.config.Instances)
+
+	segmentArgs := []string{
+		fmt.Sprintf("--execution-segment=%s", segments[i]),
+		fmt.Sprintf("--execution-segment-sequence=%s", sequence),
+	}
+
+	outputArgs := []string{"--out", fmt.Sprintf("json=%s", p.instanceOutputPath(i))}
+	for _, output := range p.config.Outputs {
+		outputArgs = append(outputArgs, "--out", output)
+	}
+
+	return p.runArgs(p.instanceAddress(i), segmentArgs, outputArgs)
+}
+
+// instance is a k6 process of a distributed run.
+type instance struct {
+	address string
+	cmd     models.ShellCommand
+	err     error
+}
+
+// runInstances runs the tests in 'instances' concurrent k6 processes, each
+// running its execution segment, and merges their outputs. It returns the
+// errors the instances exited with, other than breached thresholds, which
+// are evaluated on the merged results. The outcome of the run is stored in
+// the run result.
+func (p *pluginType) runInstances() (execError error, err error) {
+	if err := p.prepareRun(); err != nil {
+		return nil, fmt.Errorf("create output directory: %w", err)
+	}
+
+	console, err := p.newConsoleLog()
+	if err != nil {
+		return nil, err
+	}
+
+	instances := make([]*instance, p.config.Instances)
+	for i := range instances {
+		instances[i] = &instance{address: p.instanceAddress(i), cmd: p.buildCommand(p.k6Binary(), p.instanceRunArgs(i)...)}
+	}
+
+	stopHandling := handleSignals(func() {
+		for _, inst := range instances {
+			p.stopRun(inst.address, inst.cmd)
+		}
+
+		p.removeThresholdsModule()
+	})
+
+	log.Printf("Running tests on %d k6 instances...\n", len(instances))
+
+	wg := sync.WaitGroup{}
+
+	for i, inst := range instances {
+		stdout, stderr, err := startCommand(inst.cmd)
+		if err != nil {
+			inst.err = err
+			continue
+		}
+
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			prefix := fmt.Sprintf("[instance %d] ", i+1)
+
+			pipes := sync.WaitGroup{}
+			pipes.Add(2)
+
+			go console.readLines(streamStdout, prefix, stdout, &pipes)
+			go console.readLines(streamStderr, prefix, stderr, &pipes)
+
+			pipes.Wait()
+
+			inst.err = inst.cmd.Wait()
+		}()
+	}
+
+	wg.Wait()
+
+	p.result.Cancelled = stopHandling()
+
+	if err := console.Close(); err != nil {
+		log.Printf("WARNING: %s\n", err)
+	}
+
+	p.result.Logs = console.stats
+	p.result.Logs.logSummary()
+
+	var errs []error
+
+	for i, inst := range instances {
+		if inst.err != nil && exitCode(inst.err) != thresholdsBreachedExitCode {
+			errs = append(errs, fmt.Errorf("instance %d: %w", i+1, inst.err))
+		}
+	}
+
+	summary, err := p.mergeInstances()
+	if err != nil {
+		log.Printf("WARNING: merge instance outputs: %s\n", err)
+	}
+
+	p.result.Summary = summary
+	p.result.ThresholdsBreached = len(summary.BreachedThresholds()) > 0
+
+	if summary != nil {
+		log.Printf("Merged results of %d instances:\n", len(instances))
+		logFinalMetrics(summary)
+
+		for _, threshold := range summary.BreachedThresholds() {
+			log.Printf("Threshold breached: %s\n", threshold)
+		}
+	}
+
+	return errors.Join(errs...), nil
+}
+
+// mergeInstances merges the JSON outputs of the instances into a summary,
+// with the thresholds evaluated on every sample. The outputs are
+// concatenated into the output file, or the summary is written to it in
+// Projektor compat mode, and the summary is exported when needed.
+func (p *pluginType) mergeInstances() (*models.Summary, error) {
+	aggregator := merge.New()
+
+	thresholds := map[string][]string{}
+	for name, list := range p.thresholds() {
+		for _, threshold := range list {
+			thresholds[name] = append(thresholds[name], threshold.Threshold)
+		}
+	}
+
+	if err := aggregator.AddThresholds(thresholds); err != nil {
+		return nil, err
+	}
+
+	paths := make([]string, p.config.Instances)
+	for i := range paths {
+		paths[i] = p.instanceOutputPath(i)
+
+		if err := aggregator.AddFile(paths[i]); err != nil {
+			return nil, err
+		}
+	}
+
+	trendStats := p.trendStats()
+	if len(trendStats) == 0 && p.scriptOptions != nil {
+		trendStats = p.scriptOptions.Options.SummaryTrendStats
+	}
+
+	summary := aggregator.Summary(trendStats)
+
+	if p.config.OutputPath != "" && !p.config.ProjektorCompatMode {
+		if err := concatFiles(p.config.OutputPath, paths); err != nil {
+			return summary, fmt.Errorf("write output: %w", err)
+		}
+	}
+
+	if path := p.summaryExportPath(); path != "" {
+		data, err := json.MarshalIndent(summary, "", "  ")
+		if err != nil {
+			return summary, fmt.Errorf("encode summary: %w", err)
+		}
+
+		if err := os.WriteFile(path, data, 0600); err != nil {
+			return summary, fmt.Errorf("write summary export: %w", err)
+		}
+	}
+
+	return summary, nil
+}
+
+// concatFiles writes the content of the files at paths, in order, to a new
+// file at dst.
+func concatFiles(dst string, paths []string) error {
+	out, err := os.Create(dst) //nolint:gosec // path is validated and confined to the workspace
+	if err != nil {
+		return err
+	}
+
+	for _, path := range paths {
+		in, err := os.Open(path) //nolint:gosec // path is generated by the plugin
+		if err != nil {
+			return errors.Join(err, out.Close())
+		}
+
+		_, err = io.Copy(out, in)
+		if err := errors.Join(err, in.Close()); err != nil {
+			return errors.Join(err, out.Close())
+		}
+	}
+
+	return out.Close()
+}
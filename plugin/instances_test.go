@@ -0,0 +1,168 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package plugin
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/go-vela/vela-k6/models"
+	"github.com/go-vela/vela-k6/plugin/mock"
+)
+
+func TestExecutionSegments(t *testing.T) {
+	segments, sequence := executionSegments(1)
+	assert.Equal(t, []string{"0:1"}, segments)
+	assert.Equal(t, "0,1", sequence)
+
+	segments, sequence = executionSegments(3)
+	assert.Equal(t, []string{"0:1/3", "1/3:2/3", "2/3:1"}, segments)
+	assert.Equal(t, "0,1/3,2/3,1", sequence)
+}
+
+func TestCheckInstances(t *testing.T) {
+	assert.NoError(t, validateInstances(1))
+	assert.EqualError(t, validateInstances(0), "0 must be between 1 and 32")
+	assert.EqualError(t, validateInstances(33), "33 must be between 1 and 32")
+
+	p := &pluginType{config: config{Instances: 4}}
+	assert.NoError(t, p.checkInstances())
+
+	p.config.ProgressInterval = duration(minProgressInterval)
+	assert.EqualError(t, p.checkInstances(), "can't be combined with 'progress_interval', 'abort_conditions' or 'linger'")
+
+	p.config = config{Instances: 4, Linger: true}
+	assert.EqualError(t, p.checkInstances(), "can't be combined with 'progress_interval', 'abort_conditions' or 'linger'")
+
+	p.config = config{Instances: 4, APIAddress: "localhost:65533"}
+	assert.EqualError(t, p.checkInstances(), `'api_address' "localhost:65533" must have a numeric port leaving room for 4 instances`)
+
+	p.config = config{Instances: 2, APIAddress: "localhost:http"}
+	assert.Error(t, p.checkInstances())
+}
+
+func TestInstanceRunArgs(t *testing.T) {
+	p := &pluginType{
+		config: config{
+			ScriptPath: "./test/script.js",
+			OutputPath: "./output.json",
+			Outputs:    []string{"statsd"},
+			Instances:  2,
+			APIAddress: "127.0.0.1:7000",
+		},
+		tempDir: "/tmp/k6",
+	}
+
+	assert.Equal(t, []string{
+		"run", "-q", "--log-format=json", "--address=127.0.0.1:7001",
+		"--execution-segment=1/2:1", "--execution-segment-sequence=0,1/2,1",
+		"--out", "json=/tmp/k6/vela-k6-instance-2.json", "--out", "statsd",
+		"./test/script.js",
+	}, p.instanceRunArgs(1))
+}
+
+// instanceOutput is the k6 JSON output written by mock instance N, with a
+// single http_req_duration sample of N00ms.
+const instanceOutput = `{"type":"Metric","data":{"name":"http_req_duration","type":"trend","thresholds":["p(95)<250"]},"metric":"http_req_duration"}
+{"type":"Point","data":{"time":"2024-01-01T00:00:0%[1]dZ","value":%[1]d00,"tags":{}},"metric":"http_req_duration"}
+`
+
+// instanceCommandBuilder returns a function building mock k6 instances
+// that write instanceOutput to their JSON output and exit with waitErr.
+func instanceCommandBuilder(t *testing.T, waitErr error) func(string, ...string) models.ShellCommand {
+	t.Helper()
+
+	return func(name string, args ...string) models.ShellCommand {
+		for _, arg := range args {
+			path, ok := strings.CutPrefix(arg, "json=")
+			if !ok {
+				continue
+			}
+
+			var i int
+
+			_, err := fmt.Sscanf(filepath.Base(path), instanceOutputFile, &i)
+			require.NoError(t, err)
+			require.NoError(t, os.WriteFile(path, []byte(fmt.Sprintf(instanceOutput, i)), 0600))
+		}
+
+		return mock.CommandBuilderWithOutput("running\n", waitErr)(name, args...)
+	}
+}
+
+func TestRunInstances(t *testing.T) {
+	t.Run("Merged Thresholds", func(t *testing.T) {
+		t.Setenv("TMPDIR", t.TempDir())
+
+		buf := captureLog(t)
+		output := filepath.Join(t.TempDir(), "output.json")
+
+		p := &pluginType{
+			config: config{
+				ScriptPath:            "./test/script.js",
+				OutputPath:            output,
+				Instances:             3,
+				FailOnThresholdBreach: true,
+			},
+			buildCommand:     instanceCommandBuilder(t, &mock.ThresholdError{}),
+			verifyFileExists: func(string) error { return nil },
+		}
+
+		// each instance breached its threshold, only the merged p(95) counts
+		assert.EqualError(t, p.RunPerfTests(), "thresholds breached")
+		assert.Equal(t, map[string]bool{"p(95)<250": true}, p.result.Summary.Metrics["http_req_duration"].Thresholds)
+		assert.InEpsilon(t, 290.0, p.result.Summary.Metrics["http_req_duration"].Values["p(95)"], 0.01)
+
+		data, err := os.ReadFile(output)
+		require.NoError(t, err)
+		assert.Equal(t, 6, strings.Count(string(data), "\n"))
+
+		assert.Contains(t, buf.String(), "[instance 3] running\n")
+		assert.Contains(t, buf.String(), "Merged results of 3 instances:\n")
+		assert.Contains(t, buf.String(), "Threshold breached: http_req_duration: p(95)<250\n")
+	})
+
+	t.Run("Summary Export", func(t *testing.T) {
+		t.Setenv("TMPDIR", t.TempDir())
+		captureLog(t)
+
+		limit := func(v float64) *float64 { return &v }
+
+		p := &pluginType{
+			config: config{
+				ScriptPath: filepath.Join(t.TempDir(), "script.js"),
+				Instances:  2,
+				Thresholds: map[string][]models.Threshold{"http_req_duration": {{Threshold: "max<500"}}},
+				Gates:      []models.Gate{{Metric: "http_req_duration", Stat: "max", Max: limit(150)}},
+			},
+			buildCommand:     instanceCommandBuilder(t, nil),
+			verifyFileExists: func(string) error { return nil },
+		}
+
+		assert.EqualError(t, p.RunPerfTests(), "1 of 1 gates failed")
+		assert.False(t, p.result.ThresholdsBreached)
+
+		summary, err := models.ReadSummary(p.tempPath(summaryExportFile))
+		require.NoError(t, err)
+		assert.Equal(t, map[string]bool{"p(95)<250": false, "max<500": false}, summary.Metrics["http_req_duration"].Thresholds)
+	})
+
+	t.Run("Instance Error", func(t *testing.T) {
+		t.Setenv("TMPDIR", t.TempDir())
+		captureLog(t)
+
+		p := &pluginType{
+			config:           config{ScriptPath: "./test/script.js", Instances: 2},
+			buildCommand:     instanceCommandBuilder(t, &mock.ExitCodeError{Code: 108}),
+			verifyFileExists: func(string) error { return nil },
+		}
+
+		assert.EqualError(t, p.RunPerfTests(), "instance 1: exit status 108\ninstance 2: exit status 108")
+	})
+}
@@ -0,0 +1,345 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package plugin
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"maps"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/go-vela/vela-k6/models"
+	"github.com/go-vela/vela-k6/stats"
+)
+
+// k6ConfigFile is the name of the k6 config file generated in the temp
+// directory for the options the plugin passes to k6 that have no k6 run
+// flag, such as thresholds.
+const k6ConfigFile = "vela-k6-config.json"
+
+// thresholdsModulePrefix prefixes the name of the module generated next
+// to the script to override the thresholds of its options.
+const thresholdsModulePrefix = ".vela-k6-"
+
+// thresholdsModuleTemplate is the module generated to run a script
+// defining thresholds with the thresholds of the plugin. It re-exports the
+// script, with its options overridden, as k6 applies the options of the
+// script instead of those of the config file.
+const thresholdsModuleTemplate = `// Generated by vela-k6 to run %[1]s with the configured thresholds.
+import * as script from %[1]s;
+
+export * from %[1]s;
+export default script.default;
+export const options = Object.assign({}, script.options, { thresholds: %[2]s });
+`
+
+// commonJSThresholdsModuleTemplate is thresholdsModuleTemplate for
+// CommonJS scripts.
+const commonJSThresholdsModuleTemplate = `// Generated by vela-k6 to run %[1]s with the configured thresholds.
+const script = require(%[1]s);
+
+module.exports = Object.assign({}, script, { options: Object.assign({}, script.options, { thresholds: %[2]s }) });
+`
+
+// Accepted values of the 'thresholds_mode' parameter.
+const (
+	thresholdsModeMerge   = "merge"
+	thresholdsModeReplace = "replace"
+)
+
+// thresholdsModes are the accepted values of 'thresholds_mode'.
+var thresholdsModes = []string{thresholdsModeMerge, thresholdsModeReplace}
+
+// thresholdMetricPattern matches a metric name with an optional tag
+// filter, such as "http_req_duration{status:200}".
+var thresholdMetricPattern = regexp.MustCompile(`^[a-zA-Z0-9_.\-]+(\{[^{}]+\})?$`)
+
+// k6Config is the generated k6 config file, passed with --config. It
+// holds the options of the k6 config file set in 'k6_config_path', if
+// any, with the thresholds replaced.
+type k6Config struct {
+	Options    map[string]json.RawMessage
+	Thresholds map[string][]models.Threshold
+}
+
+// MarshalJSON encodes the config as a single k6 options object.
+func (c k6Config) MarshalJSON() ([]byte, error) {
+	options := maps.Clone(c.Options)
+	if options == nil {
+		options = map[string]json.RawMessage{}
+	}
+
+	thresholds, err := json.Marshal(c.Thresholds)
+	if err != nil {
+		return nil, err
+	}
+
+	options["thresholds"] = thresholds
+
+	return json.Marshal(options)
+}
+
+// k6Config returns the generated k6 config, or nil if no option needs
+// one.
+func (p *pluginType) k6Config() *k6Config {
+	if len(p.config.Thresholds) == 0 {
+		return nil
+	}
+
+	return &k6Config{Options: p.config.k6Options, Thresholds: p.thresholds()}
+}
+
+// thresholds returns the thresholds written to the generated k6 config.
+// In merge mode, the configured thresholds replace the thresholds that
+// the k6 config file and the inspected script define on the same
+// metrics, and the others are kept. In replace mode only the configured
+// thresholds are used.
+func (p *pluginType) thresholds() map[string][]models.Threshold {
+	if p.config.ThresholdsMode == thresholdsModeReplace {
+		return p.config.Thresholds
+	}
+
+	merged := map[string][]models.Threshold{}
+	maps.Copy(merged, p.config.k6Thresholds)
+
+	if p.scriptOptions != nil {
+		maps.Copy(merged, p.scriptOptions.Options.Thresholds)
+	}
+
+	maps.Copy(merged, p.config.Thresholds)
+
+	return merged
+}
+
+// configThresholds returns the thresholds of the generated k6 config
+// without those of the inspected script, which are the thresholds the
+// script is inspected with.
+func (p *pluginType) configThresholds() map[string][]models.Threshold {
+	if p.config.ThresholdsMode == thresholdsModeReplace {
+		return p.config.Thresholds
+	}
+
+	merged := map[string][]models.Threshold{}
+	maps.Copy(merged, p.config.k6Thresholds)
+	maps.Copy(merged, p.config.Thresholds)
+
+	return merged
+}
+
+// scriptDefinesThresholds returns true if the script may define
+// thresholds in its options, which k6 applies instead of those of the
+// generated config. The script is inspected with the generated config, so
+// the inspected thresholds only differ from those of the config when the
+// script defines its own. A script that wasn't inspected may define them.
+func (p *pluginType) scriptDefinesThresholds() bool {
+	if p.scriptOptions == nil {
+		return true
+	}
+
+	return !maps.EqualFunc(p.scriptOptions.Options.Thresholds, p.configThresholds(), func(a, b []models.Threshold) bool {
+		return slices.EqualFunc(a, b, func(x, y models.Threshold) bool { return x.Threshold == y.Threshold })
+	})
+}
+
+// k6ConfigPath returns the path of the k6 config file passed with
+// --config: the generated config if one is needed, otherwise the file set
+// in 'k6_config_path', or an empty string.
+func (p *pluginType) k6ConfigPath() string {
+	if p.k6Config() == nil {
+		return p.config.K6ConfigPath
+	}
+
+	return p.tempPath(k6ConfigFile)
+}
+
+// setK6ConfigPath reads the k6 config file set in 'k6_config_path' and
+// stores its path and options.
+func setK6ConfigPath(cfg *config, v any) error {
+	path := v.(string)
+
+	data, err := os.ReadFile(path) //nolint:gosec // path is validated and confined to the workspace
+	if err != nil {
+		return fmt.Errorf("read k6 config: %w", err)
+	}
+
+	var options map[string]json.RawMessage
+	if err := json.Unmarshal(data, &options); err != nil {
+		return fmt.Errorf("decode k6 config %s: %w", path, err)
+	}
+
+	var thresholds map[string][]models.Threshold
+	if raw, ok := options["thresholds"]; ok {
+		if err := json.Unmarshal(raw, &thresholds); err != nil {
+			return fmt.Errorf("decode k6 config %s: 'thresholds': %w", path, err)
+		}
+	}
+
+	cfg.K6ConfigPath = path
+	cfg.k6Options = options
+	cfg.k6Thresholds = thresholds
+
+	return nil
+}
+
+// thresholdsModulePath returns the path of the module generated to
+// override the thresholds the script defines, or an empty string if none
+// is needed. It is written next to the script with the same extension, so
+// the relative imports and files of the script resolve the same way and
+// k6 loads it the same way. Archives can't be wrapped.
+func (p *pluginType) thresholdsModulePath() string {
+	if p.k6Config() == nil || isArchive(p.config.ScriptPath) || !p.scriptDefinesThresholds() {
+		return ""
+	}
+
+	dir, name := filepath.Split(p.config.ScriptPath)
+
+	return filepath.Join(dir, thresholdsModulePrefix+name)
+}
+
+// thresholdsModule returns the source of the module overriding the
+// thresholds of the script. In merge mode the thresholds the script
+// defines when it runs are kept for the metrics without configured
+// thresholds, even if it wasn't inspected.
+func (p *pluginType) thresholdsModule() (string, error) {
+	script, err := json.Marshal("./" + filepath.Base(p.config.ScriptPath))
+	if err != nil {
+		return "", err
+	}
+
+	// HTML escaping is disabled so the expressions stay readable
+	var data strings.Builder
+
+	enc := json.NewEncoder(&data)
+	enc.SetEscapeHTML(false)
+
+	if err := enc.Encode(p.thresholds()); err != nil {
+		return "", err
+	}
+
+	thresholds := strings.TrimSpace(data.String())
+	if p.config.ThresholdsMode != thresholdsModeReplace {
+		thresholds = fmt.Sprintf("Object.assign({}, script.options && script.options.thresholds, %s)", thresholds)
+	}
+
+	if strings.EqualFold(filepath.Ext(p.config.ScriptPath), ".cjs") {
+		return fmt.Sprintf(commonJSThresholdsModuleTemplate, script, thresholds), nil
+	}
+
+	return fmt.Sprintf(thresholdsModuleTemplate, script, thresholds), nil
+}
+
+// k6ScriptPath returns the script passed to `k6 run` or `k6 archive`: the
+// module overriding its thresholds, if one is needed, or the script.
+func (p *pluginType) k6ScriptPath() string {
+	if path := p.thresholdsModulePath(); path != "" {
+		return path
+	}
+
+	return p.config.ScriptPath
+}
+
+// writeThresholdsModule writes the module overriding the thresholds of
+// the script, if one is needed.
+func (p *pluginType) writeThresholdsModule() error {
+	path := p.thresholdsModulePath()
+	if path == "" {
+		return nil
+	}
+
+	module, err := p.thresholdsModule()
+	if err != nil {
+		return fmt.Errorf("encode thresholds module: %w", err)
+	}
+
+	if err := os.WriteFile(path, []byte(module), 0600); err != nil {
+		return fmt.Errorf("write thresholds module: %w", err)
+	}
+
+	return nil
+}
+
+// removeThresholdsModule removes the module overriding the thresholds of
+// the script once k6 no longer needs it: after the run, or when the step
+// is cancelled, as k6 loads the module before the test starts.
+func (p *pluginType) removeThresholdsModule() {
+	path := p.thresholdsModulePath()
+	if path == "" {
+		return
+	}
+
+	if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		log.Printf("WARNING: remove thresholds module: %s\n", err)
+	}
+}
+
+// writeK6Config writes the generated k6 config file and the module
+// overriding the thresholds of the script, if they are needed.
+func (p *pluginType) writeK6Config() error {
+	if err := p.writeK6ConfigFile(); err != nil {
+		return err
+	}
+
+	if isArchive(p.config.ScriptPath) && p.k6Config() != nil && p.scriptOptions != nil && p.scriptDefinesThresholds() {
+		log.Printf("WARNING: archive %s defines thresholds in its options, which k6 applies instead of the thresholds in %s\n", p.config.ScriptPath, p.k6ConfigPath())
+	}
+
+	return p.writeThresholdsModule()
+}
+
+// writeK6ConfigFile writes the generated k6 config file, if one is
+// needed.
+func (p *pluginType) writeK6ConfigFile() error {
+	cfg := p.k6Config()
+	if cfg == nil {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(cfg, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode k6 config: %w", err)
+	}
+
+	if err := os.WriteFile(p.k6ConfigPath(), data, 0600); err != nil {
+		return fmt.Errorf("write k6 config: %w", err)
+	}
+
+	return nil
+}
+
+// validateThresholds checks the metric names and expressions of
+// thresholds.
+func validateThresholds(thresholds map[string][]models.Threshold) error {
+	var errs []error
+
+	for _, metric := range sortedKeys(thresholds) {
+		if !thresholdMetricPattern.MatchString(metric) {
+			errs = append(errs, fmt.Errorf("%q is not a metric name", metric))
+		}
+
+		if len(thresholds[metric]) == 0 {
+			errs = append(errs, fmt.Errorf("metric %q has no thresholds", metric))
+		}
+
+		for _, threshold := range thresholds[metric] {
+			if _, err := stats.ParseThreshold(threshold.Threshold); err != nil {
+				errs = append(errs, fmt.Errorf("metric %q: %w", metric, err))
+			}
+
+			if threshold.DelayAbortEval != "" {
+				if _, err := time.ParseDuration(threshold.DelayAbortEval); err != nil {
+					errs = append(errs, fmt.Errorf("metric %q: 'delayAbortEval' %q is not a duration", metric, threshold.DelayAbortEval))
+				}
+			}
+		}
+	}
+
+	return errors.Join(errs...)
+}
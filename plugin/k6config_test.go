@@ -0,0 +1,180 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package plugin
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-vela/vela-k6/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestK6Config(t *testing.T) {
+	configured := map[string][]models.Threshold{
+		"http_req_duration": {{Threshold: "p(95)<800", AbortOnFail: true}},
+		"checks":            {{Threshold: "rate>0.99"}},
+	}
+	script := &models.InspectResult{Options: models.ScriptOptions{Thresholds: map[string][]models.Threshold{
+		"http_req_duration": {{Threshold: "p(95)<500"}},
+		"http_req_failed":   {{Threshold: "rate<0.01"}},
+	}}}
+
+	t.Run("No Thresholds", func(t *testing.T) {
+		p := &pluginType{scriptOptions: script}
+		assert.Nil(t, p.k6Config())
+		assert.Empty(t, p.k6ConfigPath())
+	})
+	t.Run("Merge", func(t *testing.T) {
+		p := &pluginType{config: config{Thresholds: configured, ThresholdsMode: thresholdsModeMerge}, scriptOptions: script}
+		assert.Equal(t, map[string][]models.Threshold{
+			"http_req_duration": {{Threshold: "p(95)<800", AbortOnFail: true}},
+			"http_req_failed":   {{Threshold: "rate<0.01"}},
+			"checks":            {{Threshold: "rate>0.99"}},
+		}, p.k6Config().Thresholds)
+	})
+	t.Run("Merge Without Inspect", func(t *testing.T) {
+		p := &pluginType{config: config{Thresholds: configured, ThresholdsMode: thresholdsModeMerge}}
+		assert.Equal(t, configured, p.k6Config().Thresholds)
+	})
+	t.Run("Replace", func(t *testing.T) {
+		p := &pluginType{config: config{Thresholds: configured, ThresholdsMode: thresholdsModeReplace}, scriptOptions: script}
+		assert.Equal(t, configured, p.k6Config().Thresholds)
+	})
+	t.Run("K6 Config File", func(t *testing.T) {
+		cfg := config{Thresholds: configured, ThresholdsMode: thresholdsModeMerge}
+		path := filepath.Join(t.TempDir(), "k6.json")
+		require.NoError(t, os.WriteFile(path, []byte(`{"batch": 20, "thresholds": {"checks": ["rate>0.9"], "iterations": ["count>10"]}}`), 0600))
+		require.NoError(t, setK6ConfigPath(&cfg, path))
+
+		p := &pluginType{config: cfg}
+		data, err := json.Marshal(p.k6Config())
+		require.NoError(t, err)
+		assert.JSONEq(t, `{
+			"batch": 20,
+			"thresholds": {
+				"http_req_duration": [{"threshold": "p(95)<800", "abortOnFail": true}],
+				"checks": ["rate>0.99"],
+				"iterations": ["count>10"]
+			}
+		}`, string(data))
+		assert.NotEqual(t, path, p.k6ConfigPath())
+
+		p.config.Thresholds = nil
+		assert.Nil(t, p.k6Config())
+		assert.Equal(t, path, p.k6ConfigPath())
+	})
+}
+
+func TestSetK6ConfigPath(t *testing.T) {
+	dir := t.TempDir()
+	for content, expected := range map[string]string{
+		`[]`:                       "decode k6 config",
+		`{"thresholds": "fast"}`:   "'thresholds'",
+		`{"vus": 1, "thresholds"}`: "decode k6 config",
+	} {
+		path := filepath.Join(dir, "k6.json")
+		require.NoError(t, os.WriteFile(path, []byte(content), 0600))
+		assert.ErrorContains(t, setK6ConfigPath(&config{}, path), expected, content)
+	}
+
+	assert.ErrorContains(t, setK6ConfigPath(&config{}, filepath.Join(dir, "missing.json")), "read k6 config")
+}
+
+func TestValidateThresholds(t *testing.T) {
+	assert.NoError(t, validateThresholds(map[string][]models.Threshold{
+		"http_req_duration{status:200}": {{Threshold: "p(99.9) < 1500"}, {Threshold: "avg<=200", DelayAbortEval: "10s"}},
+		"checks":                        {{Threshold: "rate>0.99"}},
+		"iterations":                    {{Threshold: "count>=100"}},
+	}))
+
+	err := validateThresholds(map[string][]models.Threshold{
+		"bad metric": {{Threshold: "p(95)<500"}},
+		"empty":      {},
+		"latency":    {{Threshold: "p95 < 500"}, {Threshold: "avg<1", DelayAbortEval: "soon"}},
+	})
+	assert.ErrorContains(t, err, `"bad metric" is not a metric name`)
+	assert.ErrorContains(t, err, `metric "empty" has no thresholds`)
+	assert.ErrorContains(t, err, `metric "latency": "p95 < 500" is not a threshold expression`)
+	assert.ErrorContains(t, err, `'delayAbortEval' "soon" is not a duration`)
+}
+
+func TestThresholdsModule(t *testing.T) {
+	configured := map[string][]models.Threshold{"http_req_duration": {{Threshold: "p(95)<800", AbortOnFail: true}}}
+	script := &models.InspectResult{Options: models.ScriptOptions{Thresholds: map[string][]models.Threshold{
+		"http_req_duration": {{Threshold: "p(95)<500"}},
+		"http_req_failed":   {{Threshold: "rate<0.01"}},
+	}}}
+
+	t.Run("Merge", func(t *testing.T) {
+		dir := t.TempDir()
+
+		p := &pluginType{
+			config:        config{ScriptPath: filepath.Join(dir, "load test.js"), Thresholds: configured, ThresholdsMode: thresholdsModeMerge},
+			scriptOptions: script,
+			tempDir:       t.TempDir(),
+		}
+
+		require.NoError(t, p.writeK6Config())
+		assert.Equal(t, filepath.Join(dir, ".vela-k6-load test.js"), p.k6ScriptPath())
+		assert.Equal(t, p.k6ScriptPath(), p.runScriptPath())
+
+		module, err := os.ReadFile(p.k6ScriptPath())
+		require.NoError(t, err)
+		assert.Equal(t, `// Generated by vela-k6 to run "./load test.js" with the configured thresholds.
+import * as script from "./load test.js";
+
+export * from "./load test.js";
+export default script.default;
+export const options = Object.assign({}, script.options, { thresholds: Object.assign({}, script.options && script.options.thresholds, {"http_req_duration":[{"threshold":"p(95)<800","abortOnFail":true}],"http_req_failed":["rate<0.01"]}) });
+`, string(module))
+
+		p.removeThresholdsModule()
+		assert.NoFileExists(t, filepath.Join(dir, ".vela-k6-load test.js"))
+	})
+	t.Run("Replace", func(t *testing.T) {
+		p := &pluginType{
+			config:        config{ScriptPath: "./k6/script.ts", Thresholds: configured, ThresholdsMode: thresholdsModeReplace},
+			scriptOptions: script,
+		}
+
+		module, err := p.thresholdsModule()
+		require.NoError(t, err)
+		assert.Equal(t, filepath.Join("k6", ".vela-k6-script.ts"), p.thresholdsModulePath())
+		assert.Contains(t, module, `import * as script from "./script.ts";`)
+		assert.Contains(t, module, `export const options = Object.assign({}, script.options, { thresholds: {"http_req_duration":[{"threshold":"p(95)<800","abortOnFail":true}]} });`)
+	})
+	t.Run("CommonJS", func(t *testing.T) {
+		p := &pluginType{config: config{ScriptPath: "./script.cjs", Thresholds: configured, ThresholdsMode: thresholdsModeReplace}}
+
+		module, err := p.thresholdsModule()
+		require.NoError(t, err)
+		assert.Equal(t, ".vela-k6-script.cjs", p.thresholdsModulePath())
+		assert.Equal(t, `// Generated by vela-k6 to run "./script.cjs" with the configured thresholds.
+const script = require("./script.cjs");
+
+module.exports = Object.assign({}, script, { options: Object.assign({}, script.options, { thresholds: {"http_req_duration":[{"threshold":"p(95)<800","abortOnFail":true}]} }) });
+`, module)
+	})
+	t.Run("Not Needed", func(t *testing.T) {
+		// the script was inspected with the generated config and reported
+		// its thresholds, so it defines none of its own
+		inspected := &models.InspectResult{Options: models.ScriptOptions{Thresholds: map[string][]models.Threshold{
+			"http_req_duration": {{Threshold: "p(95)<800"}},
+			"http_req_failed":   {{Threshold: "rate<0.01"}},
+		}}}
+		k6Thresholds := map[string][]models.Threshold{"http_req_failed": {{Threshold: "rate<0.01"}}}
+
+		for _, p := range []*pluginType{
+			{config: config{ScriptPath: "./script.js"}, scriptOptions: script},
+			{config: config{ScriptPath: "./script.tar", Thresholds: configured}},
+			{config: config{ScriptPath: "./script.js", Thresholds: configured, k6Thresholds: k6Thresholds}, scriptOptions: inspected},
+		} {
+			assert.Empty(t, p.thresholdsModulePath())
+			assert.Equal(t, p.config.ScriptPath, p.k6ScriptPath())
+		}
+	})
+}
@@ -0,0 +1,146 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package plugin
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+)
+
+// Levels of the k6 JSON log entries that are counted by the plugin.
+const (
+	k6LogLevelError   = "error"
+	k6LogLevelWarning = "warning"
+)
+
+// k6LogSourceConsole is the source of the k6 log entries written by the
+// script with the console API.
+const k6LogSourceConsole = "console"
+
+// maxLogSummaryMessages is the number of distinct messages listed in the
+// log summary logged after the run.
+const maxLogSummaryMessages = 10
+
+// k6LogEntry is a single line of k6 output written with
+// --log-format=json.
+type k6LogEntry struct {
+	Level   string
+	Message string
+	Source  string
+	Fields  map[string]any
+}
+
+// parseK6LogLine decodes a k6 JSON log line. It returns false if the line
+// is not a JSON log entry, such as the end-of-test summary.
+func parseK6LogLine(line string) (k6LogEntry, bool) {
+	if !strings.HasPrefix(line, "{") {
+		return k6LogEntry{}, false
+	}
+
+	var fields map[string]any
+	if err := json.Unmarshal([]byte(line), &fields); err != nil {
+		return k6LogEntry{}, false
+	}
+
+	level, ok := fields["level"].(string)
+	if !ok {
+		return k6LogEntry{}, false
+	}
+
+	message, _ := fields["msg"].(string)
+	source, _ := fields["source"].(string)
+
+	for _, key := range []string{"level", "msg", "source", "time"} {
+		delete(fields, key)
+	}
+
+	return k6LogEntry{Level: level, Message: message, Source: source, Fields: fields}, true
+}
+
+// String renders the entry for the Vela log, with the level in upper
+// case followed by the message and the other fields.
+func (e k6LogEntry) String() string {
+	b := strings.Builder{}
+	b.WriteString(strings.ToUpper(e.Level))
+	b.WriteString(": ")
+
+	if e.Source == k6LogSourceConsole {
+		b.WriteString("script: ")
+	}
+
+	b.WriteString(e.Message)
+
+	for _, key := range sortedKeys(e.Fields) {
+		fmt.Fprintf(&b, " %s=%v", key, e.Fields[key])
+	}
+
+	return b.String()
+}
+
+// k6LogStats counts the error and warning entries of the k6 JSON logs.
+type k6LogStats struct {
+	Errors       int
+	ScriptErrors int
+	Warnings     int
+	// Messages counts the occurrences of each error and warning message.
+	Messages map[string]int
+}
+
+// add counts the entry if it is an error or a warning.
+func (s *k6LogStats) add(e k6LogEntry) {
+	switch e.Level {
+	case k6LogLevelError:
+		s.Errors++
+
+		if e.Source == k6LogSourceConsole {
+			s.ScriptErrors++
+		}
+	case k6LogLevelWarning:
+		s.Warnings++
+	default:
+		return
+	}
+
+	if s.Messages == nil {
+		s.Messages = map[string]int{}
+	}
+
+	s.Messages[strings.ToUpper(e.Level)+": "+e.Message]++
+}
+
+// logSummary logs the number of errors and warnings and the most
+// frequent messages.
+func (s *k6LogStats) logSummary() {
+	if s.Errors == 0 && s.Warnings == 0 {
+		return
+	}
+
+	log.Printf("k6 logged %d errors (%d from the script) and %d warnings\n", s.Errors, s.ScriptErrors, s.Warnings)
+
+	messages := sortedKeys(s.Messages)
+	sort.SliceStable(messages, func(i, j int) bool {
+		return s.Messages[messages[i]] > s.Messages[messages[j]]
+	})
+
+	for i, message := range messages {
+		if i == maxLogSummaryMessages {
+			log.Printf("  ... and %d more distinct messages\n", len(messages)-i)
+			break
+		}
+
+		log.Printf("  %dx %s\n", s.Messages[message], message)
+	}
+}
+
+// checkScriptErrors returns an error if k6 logged more errors than
+// 'max_script_errors' allows.
+func (p *pluginType) checkScriptErrors() error {
+	if p.config.MaxScriptErrors == nil || p.result.Logs.Errors <= *p.config.MaxScriptErrors {
+		return nil
+	}
+
+	return fmt.Errorf("k6 logged %d errors, more than the %d allowed by 'max_script_errors'", p.result.Logs.Errors, *p.config.MaxScriptErrors)
+}
@@ -0,0 +1,82 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package plugin
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseK6LogLine(t *testing.T) {
+	entry, ok := parseK6LogLine(`{"level":"warning","msg":"Request Failed","error":"dial tcp: i/o timeout","time":"2024-01-01T00:00:00Z"}`)
+	require.True(t, ok)
+	assert.Equal(t, "WARNING: Request Failed error=dial tcp: i/o timeout", entry.String())
+
+	entry, ok = parseK6LogLine(`{"level":"error","msg":"checkout failed","source":"console"}`)
+	require.True(t, ok)
+	assert.Equal(t, "ERROR: script: checkout failed", entry.String())
+
+	for _, line := range []string{"     ✓ checks.........: 100.00%", `{"not": "a log"}`, "{broken"} {
+		_, ok := parseK6LogLine(line)
+		assert.False(t, ok, line)
+	}
+}
+
+func TestK6LogStats(t *testing.T) {
+	buf := captureLog(t)
+	stats := k6LogStats{}
+
+	for _, line := range []string{
+		`{"level":"info","msg":"starting"}`,
+		`{"level":"error","msg":"checkout failed","source":"console"}`,
+		`{"level":"error","msg":"checkout failed","source":"console"}`,
+		`{"level":"error","msg":"GoError: unexpected EOF"}`,
+		`{"level":"warning","msg":"Request Failed"}`,
+	} {
+		entry, ok := parseK6LogLine(line)
+		require.True(t, ok)
+		stats.add(entry)
+	}
+
+	assert.Equal(t, 3, stats.Errors)
+	assert.Equal(t, 2, stats.ScriptErrors)
+	assert.Equal(t, 1, stats.Warnings)
+
+	stats.logSummary()
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	assert.Equal(t, []string{
+		"k6 logged 3 errors (2 from the script) and 1 warnings",
+		"  2x ERROR: checkout failed",
+		"  1x ERROR: GoError: unexpected EOF",
+		"  1x WARNING: Request Failed",
+	}, lines)
+}
+
+func TestCheckScriptErrors(t *testing.T) {
+	limit := 1
+	p := &pluginType{result: runResult{Logs: k6LogStats{Errors: 1}}}
+	assert.NoError(t, p.checkScriptErrors())
+
+	p.config.MaxScriptErrors = &limit
+	assert.NoError(t, p.checkScriptErrors())
+
+	p.result.Logs.Errors = 2
+	assert.EqualError(t, p.checkScriptErrors(), "k6 logged 2 errors, more than the 1 allowed by 'max_script_errors'")
+}
+
+func TestConsoleLogRendersK6Logs(t *testing.T) {
+	buf := captureLog(t)
+	p := &pluginType{}
+
+	c, err := p.newConsoleLog()
+	require.NoError(t, err)
+	c.writeLine(streamStderr, "", `{"level":"error","msg":"checkout failed","source":"console"}`)
+	require.NoError(t, c.Close())
+
+	assert.Equal(t, "ERROR: script: checkout failed\n", buf.String())
+	assert.Equal(t, 1, c.stats.ScriptErrors)
+}
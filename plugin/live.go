@@ -0,0 +1,358 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/go-vela/vela-k6/k6api"
+	"github.com/go-vela/vela-k6/models"
+)
+
+// apiPollInterval is how often the status and metrics of the running test
+// are read from the k6 REST API.
+const apiPollInterval = time.Second
+
+// lingerTimeout is how long k6 is given to exit once it is stopped
+// through its REST API after lingering, before it is interrupted.
+const lingerTimeout = 5 * time.Second
+
+// validateAPIAddress checks that 'api_address' is a host:port on the
+// loopback interface, so the k6 REST API is not exposed outside the
+// container.
+func validateAPIAddress(v any) error {
+	host, port, err := net.SplitHostPort(v.(string))
+	if err != nil || port == "" {
+		return fmt.Errorf("%q is not a host:port address", v)
+	}
+
+	if host == "localhost" {
+		return nil
+	}
+
+	if ip := net.ParseIP(host); ip == nil || !ip.IsLoopback() {
+		return fmt.Errorf("%q must be a loopback address, such as %s", v, k6api.DefaultAddress)
+	}
+
+	return nil
+}
+
+// apiAddress returns the address k6 serves its REST API on.
+func (p *pluginType) apiAddress() string {
+	if p.config.APIAddress == "" {
+		return k6api.DefaultAddress
+	}
+
+	return p.config.APIAddress
+}
+
+// apiPoller reads the status and metrics of the running test from the k6
+// REST API. Requests fail until k6 has started the API and after it
+// exits, which is expected and not logged.
+type apiPoller struct {
+	client   *k6api.Client
+	onStatus func(k6api.Status)
+	onEnded  func()
+	ended    bool
+	mu       sync.Mutex
+	metrics  []k6api.Metric
+	stop     chan struct{}
+	wg       sync.WaitGroup
+}
+
+// newAPIPoller returns a poller for the k6 instance of client. onStatus,
+// if set, is called with every status read, and onEnded, if set, once
+// the test has ended and its final metrics are read.
+func newAPIPoller(client *k6api.Client, onStatus func(k6api.Status), onEnded func()) *apiPoller {
+	return &apiPoller{client: client, onStatus: onStatus, onEnded: onEnded, stop: make(chan struct{})}
+}
+
+// Start polls the API every apiPollInterval until Stop is called.
+func (a *apiPoller) Start() {
+	a.wg.Add(1)
+
+	go func() {
+		defer a.wg.Done()
+
+		ticker := time.NewTicker(apiPollInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-a.stop:
+				return
+			case <-ticker.C:
+				a.poll()
+			}
+		}
+	}()
+}
+
+// poll reads the status and the metrics of the test once. The metrics
+// read after the status reports the test as ended are final.
+func (a *apiPoller) poll() {
+	ctx, cancel := context.WithTimeout(context.Background(), apiPollInterval)
+	defer cancel()
+
+	var (
+		status     k6api.Status
+		statusRead bool
+	)
+
+	if a.onStatus != nil || a.onEnded != nil {
+		var err error
+
+		status, err = a.client.Status(ctx)
+		statusRead = err == nil
+	}
+
+	if statusRead && a.onStatus != nil {
+		a.onStatus(status)
+	}
+
+	metrics, err := a.client.Metrics(ctx)
+	if err != nil {
+		return
+	}
+
+	a.mu.Lock()
+	a.metrics = metrics
+	a.mu.Unlock()
+
+	if statusRead && a.onEnded != nil && !a.ended && testEnded(status) {
+		a.ended = true
+		a.onEnded()
+	}
+}
+
+// testEnded returns true if the status reports the test as ended or
+// interrupted.
+func testEnded(status k6api.Status) bool {
+	state := status.State()
+	return state == "ended" || state == "interrupted"
+}
+
+// Stop stops polling and returns the last metrics read, if any.
+func (a *apiPoller) Stop() []k6api.Metric {
+	close(a.stop)
+	a.wg.Wait()
+
+	return a.metrics
+}
+
+// summaryFromMetrics returns the metrics read from the k6 REST API as a
+// summary, or nil if there are none. The summary has no thresholds.
+func summaryFromMetrics(metrics []k6api.Metric) *models.Summary {
+	if len(metrics) == 0 {
+		return nil
+	}
+
+	summary := &models.Summary{Metrics: make(map[string]models.Metric, len(metrics))}
+	for _, metric := range metrics {
+		summary.Metrics[metric.Name] = models.Metric{Values: metric.Sample}
+	}
+
+	return summary
+}
+
+// watchRun starts everything that follows the k6 run of cmd: the REST API
+// poller, the metrics stream with the progress reporter and the abort
+// monitor, and the handler that stops k6 gracefully when the step is
+// cancelled. With 'linger', k6 is stopped once the final metrics are
+// read. It returns a function that stops them once k6 exits and stores
+// their outcome in the run result.
+func (p *pluginType) watchRun(cmd models.ShellCommand) (stop func(), err error) {
+	start := time.Now()
+	exited := make(chan struct{})
+
+	var (
+		progress *progressReporter
+		monitor  *abortMonitor
+		stream   *metricsStream
+		onStatus func(k6api.Status)
+		onEnded  func()
+	)
+
+	if p.config.Linger {
+		onEnded = func() { p.endLinger(cmd, exited) }
+	}
+
+	if p.config.ProgressInterval > 0 {
+		progress = newProgressReporter(start)
+		onStatus = progress.setStatus
+	}
+
+	if len(p.config.AbortConditions) > 0 {
+		monitor = newAbortMonitor(start, p.config.AbortConditions, func(cond abortCondition) {
+			p.abortRun(cmd, cond)
+		})
+	}
+
+	if p.followsMetrics() {
+		var handlers []func(metricPoint)
+
+		if progress != nil {
+			handlers = append(handlers, progress.add)
+		}
+
+		if monitor != nil {
+			handlers = append(handlers, monitor.add)
+		}
+
+		stream = newMetricsStream(p.metricsStreamPath(), handlers...)
+		if err := stream.Start(); err != nil {
+			return nil, fmt.Errorf("follow k6 metrics: %w", err)
+		}
+	}
+
+	poller := newAPIPoller(k6api.New(p.apiAddress()), onStatus, onEnded)
+	poller.Start()
+
+	if progress != nil {
+		progress.Start(time.Duration(p.config.ProgressInterval))
+	}
+
+	if monitor != nil {
+		monitor.Start()
+	}
+
+	stopHandling := handleSignals(func() {
+		p.stopRun(p.apiAddress(), cmd)
+		p.removeThresholdsModule()
+	})
+
+	return func() {
+		close(exited)
+
+		p.result.Cancelled = stopHandling()
+
+		if stream != nil {
+			stream.Stop()
+		}
+
+		if progress != nil {
+			progress.Stop()
+		}
+
+		if monitor != nil {
+			if cond := monitor.Stop(); cond != nil {
+				p.result.AbortedBy = cond.Expression
+			}
+		}
+
+		p.result.APISummary = summaryFromMetrics(poller.Stop())
+	}, nil
+}
+
+// handleSignals calls stop when the step is cancelled with SIGINT or
+// SIGTERM, until the returned function is called. That function reports
+// whether the step was cancelled.
+func handleSignals(stop func()) func() bool {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+
+	done := make(chan struct{})
+	cancelled := false
+
+	wg := sync.WaitGroup{}
+	wg.Add(1)
+
+	go func() {
+		defer wg.Done()
+
+		select {
+		case sig := <-signals:
+			log.Printf("Received %s, stopping k6...\n", sig)
+
+			cancelled = true
+
+			stop()
+		case <-done:
+		}
+	}()
+
+	return func() bool {
+		signal.Stop(signals)
+		close(done)
+		wg.Wait()
+
+		return cancelled
+	}
+}
+
+// stopRun stops the running k6 test gracefully through its REST API on
+// address, so the teardown and the end-of-test summary still run, or
+// interrupts k6 if the API can't be reached.
+func (p *pluginType) stopRun(address string, cmd models.ShellCommand) {
+	err := k6api.New(address).Stop(context.Background())
+	if err == nil {
+		return
+	}
+
+	log.Printf("WARNING: stop k6 through its REST API: %s\n", err)
+
+	interrupt(cmd)
+}
+
+// endLinger stops k6 lingering after the test through its REST API, and
+// interrupts it if it hasn't exited within lingerTimeout.
+func (p *pluginType) endLinger(cmd models.ShellCommand, exited <-chan struct{}) {
+	p.stopRun(p.apiAddress(), cmd)
+
+	select {
+	case <-exited:
+	case <-time.After(lingerTimeout):
+		interrupt(cmd)
+	}
+}
+
+// interrupt sends an interrupt signal to the k6 process of cmd.
+func interrupt(cmd models.ShellCommand) {
+	if c, ok := cmd.(*exec.Cmd); ok && c.Process != nil {
+		if err := c.Process.Signal(os.Interrupt); err != nil {
+			log.Printf("WARNING: interrupt k6: %s\n", err)
+		}
+	}
+}
+
+// logFinalMetrics logs the main metrics of the run, read from the summary
+// export or the k6 REST API, when no output file was requested.
+func logFinalMetrics(summary *models.Summary) {
+	if summary == nil {
+		return
+	}
+
+	var parts []string
+
+	for _, stat := range []struct{ metric, stat, format string }{
+		{"iterations", "count", "%.0f iterations"},
+		{"http_reqs", "count", "%.0f requests"},
+		{"http_req_failed", "rate", "%.2f%% failed"},
+		{"http_req_duration", "p(95)", "p(95) %.0fms"},
+	} {
+		value, ok := summary.Metrics[stat.metric].Value(stat.stat)
+		if !ok {
+			continue
+		}
+
+		if stat.stat == "rate" {
+			value *= 100
+		}
+
+		parts = append(parts, fmt.Sprintf(stat.format, value))
+	}
+
+	if len(parts) > 0 {
+		log.Printf("Final metrics: %s\n", strings.Join(parts, ", "))
+	}
+}
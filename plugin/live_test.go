@@ -0,0 +1,149 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package plugin
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-vela/vela-k6/k6api"
+	"github.com/go-vela/vela-k6/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// k6APIServer returns the address of a fake k6 REST API of a running test
+// and the methods of the requests it received on /v1/status.
+func k6APIServer(t *testing.T) (string, *[]string) {
+	t.Helper()
+
+	return k6APIServerWithStatus(t, 7)
+}
+
+// k6APIServerWithStatus returns the address of a fake k6 REST API
+// reporting the execution status and the methods of the requests it
+// received on /v1/status.
+func k6APIServerWithStatus(t *testing.T, status int) (string, *[]string) {
+	t.Helper()
+
+	var statusMethods []string
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/status":
+			statusMethods = append(statusMethods, r.Method)
+			_, _ = fmt.Fprintf(w, `{"data": {"type": "status", "id": "default", "attributes": {"status": %d, "vus": 12}}}`, status)
+		case "/v1/metrics":
+			_, _ = w.Write([]byte(`{"data": [
+				{"type": "metrics", "id": "http_reqs", "attributes": {"type": "counter", "sample": {"count": 300, "rate": 10}}},
+				{"type": "metrics", "id": "http_req_failed", "attributes": {"type": "rate", "sample": {"rate": 0.015}}},
+				{"type": "metrics", "id": "http_req_duration", "attributes": {"type": "trend", "sample": {"avg": 80, "p(95)": 212.4}}}
+			]}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	return strings.TrimPrefix(srv.URL, "http://"), &statusMethods
+}
+
+func TestValidateAPIAddress(t *testing.T) {
+	for _, address := range []string{"localhost:6565", "127.0.0.1:6566", "[::1]:7000"} {
+		assert.NoError(t, validateAPIAddress(address), address)
+	}
+
+	assert.ErrorContains(t, validateAPIAddress("6565"), "is not a host:port address")
+	assert.ErrorContains(t, validateAPIAddress("0.0.0.0:6565"), "must be a loopback address")
+	assert.ErrorContains(t, validateAPIAddress("k6.example.com:6565"), "must be a loopback address")
+}
+
+func TestAPIPoller(t *testing.T) {
+	address, _ := k6APIServer(t)
+
+	var statuses []k6api.Status
+
+	poller := newAPIPoller(k6api.New(address), func(status k6api.Status) { statuses = append(statuses, status) }, func() { t.Error("test not ended") })
+	poller.poll()
+
+	assert.Equal(t, []k6api.Status{{Status: 7, VUs: 12}}, statuses)
+	assert.Equal(t, &models.Summary{Metrics: map[string]models.Metric{
+		"http_reqs":         {Values: map[string]float64{"count": 300, "rate": 10}},
+		"http_req_failed":   {Values: map[string]float64{"rate": 0.015}},
+		"http_req_duration": {Values: map[string]float64{"avg": 80, "p(95)": 212.4}},
+	}}, summaryFromMetrics(poller.Stop()))
+
+	assert.Nil(t, summaryFromMetrics(nil))
+}
+
+func TestAPIPollerEnded(t *testing.T) {
+	address, _ := k6APIServerWithStatus(t, 9)
+
+	ended := 0
+
+	poller := newAPIPoller(k6api.New(address), nil, func() { ended++ })
+	poller.poll()
+	poller.poll()
+
+	assert.Equal(t, 1, ended)
+	assert.Len(t, poller.Stop(), 3)
+}
+
+func TestWatchRun(t *testing.T) {
+	t.Run("Output File", func(t *testing.T) {
+		address, statusMethods := k6APIServer(t)
+		p := &pluginType{config: config{APIAddress: address, OutputPath: "output.json"}}
+
+		stop, err := p.watchRun(nil)
+		require.NoError(t, err)
+		time.Sleep(apiPollInterval + apiPollInterval/2)
+		stop()
+
+		assert.Empty(t, *statusMethods, "status is only polled for progress or lingering")
+		assert.Contains(t, p.result.APISummary.Metrics, "http_reqs")
+		assert.False(t, p.result.Cancelled)
+
+		p.stopRun(address, nil)
+		assert.Equal(t, []string{http.MethodPatch}, *statusMethods)
+	})
+	t.Run("Linger", func(t *testing.T) {
+		address, statusMethods := k6APIServerWithStatus(t, 9)
+		p := &pluginType{config: config{APIAddress: address, Linger: true}}
+
+		stop, err := p.watchRun(nil)
+		require.NoError(t, err)
+		time.Sleep(apiPollInterval + apiPollInterval/2)
+		stop()
+
+		// k6 is stopped once the test has ended and its metrics are read
+		assert.Equal(t, []string{http.MethodGet, http.MethodPatch}, (*statusMethods)[:2])
+		assert.Contains(t, p.result.APISummary.Metrics, "http_reqs")
+	})
+}
+
+func TestLogFinalMetrics(t *testing.T) {
+	buf := captureLog(t)
+
+	logFinalMetrics(nil)
+	logFinalMetrics(&models.Summary{Metrics: map[string]models.Metric{
+		"http_reqs":         {Values: map[string]float64{"count": 300}},
+		"http_req_failed":   {Values: map[string]float64{"rate": 0.015}},
+		"http_req_duration": {Values: map[string]float64{"p(95)": 212.4}},
+	}})
+
+	assert.Equal(t, "Final metrics: 300 requests, 1.50% failed, p(95) 212ms\n", buf.String())
+}
+
+func TestProgressSetStatus(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	r := newProgressReporter(start)
+	r.add(metricPoint{Metric: "vus", Value: 3})
+	r.setStatus(k6api.Status{Status: 7, VUs: 12})
+
+	assert.Equal(t, "Progress (running): 10s elapsed, 12 VUs, 0 iterations, 0.0 req/s, 0.00% errors, p(95) -",
+		r.status(start.Add(10*time.Second), 10*time.Second))
+}
@@ -0,0 +1,174 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package plugin
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// metricsStreamFile is the name of the k6 JSON output written to the temp
+// directory when the plugin follows the metrics during the run but no
+// JSON output file was requested.
+const metricsStreamFile = "vela-k6-metrics.json"
+
+// metricsPollInterval is how often the metrics stream is read for new
+// points.
+const metricsPollInterval = 250 * time.Millisecond
+
+// metricPoint is a single sample of the k6 JSON output.
+type metricPoint struct {
+	Metric string
+	Time   time.Time
+	Value  float64
+	Tags   map[string]string
+}
+
+// k6OutputLine is a line of the k6 JSON output. Only lines of type
+// "Point" hold samples.
+type k6OutputLine struct {
+	Type   string `json:"type"`
+	Metric string `json:"metric"`
+	Data   struct {
+		Time  time.Time         `json:"time"`
+		Value float64           `json:"value"`
+		Tags  map[string]string `json:"tags"`
+	} `json:"data"`
+}
+
+// parseMetricPoint decodes a line of the k6 JSON output. It returns false
+// if the line is not a sample.
+func parseMetricPoint(line string) (metricPoint, bool) {
+	var out k6OutputLine
+	if err := json.Unmarshal([]byte(line), &out); err != nil || out.Type != "Point" {
+		return metricPoint{}, false
+	}
+
+	return metricPoint{Metric: out.Metric, Time: out.Data.Time, Value: out.Data.Value, Tags: out.Data.Tags}, true
+}
+
+// followsMetrics returns true if the plugin reads the k6 metrics while
+// the test runs.
+func (p *pluginType) followsMetrics() bool {
+	return p.config.ProgressInterval > 0 || len(p.config.AbortConditions) > 0
+}
+
+// metricsStreamPath returns the k6 JSON output the plugin follows during
+// the run, or an empty string if it doesn't need one. The JSON output set
+// in 'output_path' is reused when present.
+func (p *pluginType) metricsStreamPath() string {
+	if !p.followsMetrics() {
+		return ""
+	}
+
+	if p.config.OutputPath != "" && !p.config.ProjektorCompatMode {
+		return p.config.OutputPath
+	}
+
+	return p.tempPath(metricsStreamFile)
+}
+
+// metricsStream follows the k6 JSON output while k6 writes it, and passes
+// every sample to the handlers.
+type metricsStream struct {
+	path     string
+	handlers []func(metricPoint)
+	stop     chan struct{}
+	wg       sync.WaitGroup
+}
+
+// newMetricsStream returns a stream of the k6 JSON output at path.
+func newMetricsStream(path string, handlers ...func(metricPoint)) *metricsStream {
+	return &metricsStream{path: path, handlers: handlers, stop: make(chan struct{})}
+}
+
+// Start follows the output in the background until Stop is called. Any
+// previous content of the file is truncated so stale samples are not
+// read.
+func (s *metricsStream) Start() error {
+	if err := os.MkdirAll(filepath.Dir(s.path), os.FileMode(0755)); err != nil {
+		return err
+	}
+
+	f, err := os.Create(s.path) //nolint:gosec // path is validated and confined to the workspace
+	if err != nil {
+		return err
+	}
+
+	s.wg.Add(1)
+
+	go s.follow(f)
+
+	return nil
+}
+
+// Stop reads the rest of the output and waits for the stream to end.
+func (s *metricsStream) Stop() {
+	close(s.stop)
+	s.wg.Wait()
+}
+
+// follow reads complete lines from f, polling for new content until the
+// stream is stopped.
+func (s *metricsStream) follow(f *os.File) {
+	defer s.wg.Done()
+	defer f.Close()
+
+	reader := bufio.NewReader(f)
+	ticker := time.NewTicker(metricsPollInterval)
+
+	defer ticker.Stop()
+
+	var partial strings.Builder
+
+	for {
+		stopped := false
+
+		select {
+		case <-s.stop:
+			stopped = true
+		case <-ticker.C:
+		}
+
+		for {
+			chunk, err := reader.ReadString('\n')
+			partial.WriteString(chunk)
+
+			if err != nil {
+				if !errors.Is(err, io.EOF) {
+					log.Printf("WARNING: read k6 metrics: %s\n", err)
+					return
+				}
+
+				break
+			}
+
+			s.handle(partial.String())
+			partial.Reset()
+		}
+
+		if stopped {
+			return
+		}
+	}
+}
+
+// handle passes the sample of a line to every handler.
+func (s *metricsStream) handle(line string) {
+	point, ok := parseMetricPoint(line)
+	if !ok {
+		return
+	}
+
+	for _, handler := range s.handlers {
+		handler(point)
+	}
+}
@@ -0,0 +1,79 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package plugin
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseMetricPoint(t *testing.T) {
+	point, ok := parseMetricPoint(`{"type":"Point","metric":"http_req_duration","data":{"time":"2024-01-01T00:00:00Z","value":123.5,"tags":{"status":"200"}}}`)
+	require.True(t, ok)
+	assert.Equal(t, "http_req_duration", point.Metric)
+	assert.Equal(t, 123.5, point.Value)
+	assert.Equal(t, map[string]string{"status": "200"}, point.Tags)
+	assert.Equal(t, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), point.Time)
+
+	for _, line := range []string{`{"type":"Metric","metric":"vus","data":{"type":"gauge"}}`, "{broken", ""} {
+		_, ok := parseMetricPoint(line)
+		assert.False(t, ok, line)
+	}
+}
+
+func TestMetricsStreamPath(t *testing.T) {
+	dir := t.TempDir()
+	temp := filepath.Join(dir, metricsStreamFile)
+
+	assert.Empty(t, (&pluginType{}).metricsStreamPath())
+	assert.Equal(t, temp, (&pluginType{config: config{ProgressInterval: duration(time.Minute)}, tempDir: dir}).metricsStreamPath())
+	assert.Equal(t, "out.json", (&pluginType{config: config{ProgressInterval: duration(time.Minute), OutputPath: "out.json"}, tempDir: dir}).metricsStreamPath())
+	assert.Equal(t, temp, (&pluginType{config: config{ProgressInterval: duration(time.Minute), OutputPath: "out.json", ProjektorCompatMode: true}, tempDir: dir}).metricsStreamPath())
+
+	p := &pluginType{config: config{ScriptPath: "./test/script.js", ProgressInterval: duration(time.Minute)}, tempDir: dir}
+	assert.Contains(t, p.k6RunArgs(), "json="+temp)
+}
+
+func TestMetricsStream(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "metrics.json")
+
+	var (
+		mu     sync.Mutex
+		values []float64
+	)
+
+	stream := newMetricsStream(path, func(point metricPoint) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		values = append(values, point.Value)
+	})
+	require.NoError(t, stream.Start())
+
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0600)
+	require.NoError(t, err)
+
+	for i := range 3 {
+		_, err := fmt.Fprintf(f, `{"type":"Point","metric":"vus","data":{"value":%d}}`+"\n", i)
+		require.NoError(t, err)
+	}
+
+	// a partial line is only read once it is complete
+	_, err = f.WriteString(`{"type":"Point","metric":"vus",`)
+	require.NoError(t, err)
+	time.Sleep(2 * metricsPollInterval)
+	_, err = f.WriteString(`"data":{"value":3}}` + "\n")
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	stream.Stop()
+
+	assert.Equal(t, []float64{0, 1, 2, 3}, values)
+}
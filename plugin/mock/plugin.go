@@ -5,6 +5,7 @@
 package mock
 
 import (
+	"fmt"
 	"io"
 	"os/exec"
 	"strings"
@@ -17,6 +18,7 @@ const thresholdsBreachedExitCode = 99
 // Command is a mock implementation of the models.ShellCommand interface.
 type Command struct {
 	args          []string
+	stdout        string
 	waitErr       error
 	stdoutPipeErr error
 	stderrPipeErr error
@@ -40,7 +42,7 @@ func (m *Command) String() (str string) {
 
 // StdoutPipe is a mock implementation of the StdoutPipe method.
 func (m *Command) StdoutPipe() (io.ReadCloser, error) {
-	dummyReader := strings.NewReader("")
+	dummyReader := strings.NewReader(m.stdout)
 	return io.NopCloser(dummyReader), m.stdoutPipeErr
 }
 
@@ -64,6 +66,19 @@ func CommandBuilderWithError(waitErr error, stdoutPipeErr error, stderrPipeErr e
 	}
 }
 
+// CommandBuilderWithOutput returns a function that will return a
+// mock.Command which will write stdout to its stdout pipe and return the
+// specified waitErr on cmd.Wait().
+func CommandBuilderWithOutput(stdout string, waitErr error) func(string, ...string) models.ShellCommand {
+	return func(name string, args ...string) models.ShellCommand {
+		return &Command{
+			args:    append([]string{name}, args...),
+			stdout:  stdout,
+			waitErr: waitErr,
+		}
+	}
+}
+
 // ThresholdError is a mock implementation of the exec.ExitError interface
 // that simulates a threshold breach error.
 type ThresholdError struct {
@@ -79,3 +94,19 @@ func (m *ThresholdError) ExitCode() int {
 func (m *ThresholdError) Error() string {
 	return "This is a mock threshold breach error"
 }
+
+// ExitCodeError is a mock implementation of the models.ErrorWithExitCode
+// interface that simulates a command exiting with Code.
+type ExitCodeError struct {
+	Code int
+}
+
+// ExitCode returns the exit code of the mock error.
+func (m *ExitCodeError) ExitCode() int {
+	return m.Code
+}
+
+// Error returns a string representation of the mock error.
+func (m *ExitCodeError) Error() string {
+	return fmt.Sprintf("exit status %d", m.Code)
+}
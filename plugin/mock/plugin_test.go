@@ -56,8 +56,26 @@ func TestCommandBuilderWithError(t *testing.T) {
 	assert.ErrorContains(t, result("start").Wait(), "some error")
 }
 
+func TestCommandBuilderWithOutput(t *testing.T) {
+	result := CommandBuilderWithOutput("some output", nil)("k6")
+
+	stdout, err := result.StdoutPipe()
+	require.NoError(t, err)
+
+	b, err := io.ReadAll(stdout)
+	assert.NoError(t, err)
+	assert.Equal(t, "some output", string(b))
+	assert.NoError(t, result.Wait())
+}
+
 func TestThresholdError(t *testing.T) {
 	th := &ThresholdError{}
 	assert.Equal(t, th.ExitCode(), thresholdsBreachedExitCode)
 	assert.Contains(t, th.Error(), "mock")
 }
+
+func TestExitCodeError(t *testing.T) {
+	err := &ExitCodeError{Code: 107}
+	assert.Equal(t, 107, err.ExitCode())
+	assert.Equal(t, "exit status 107", err.Error())
+}
@@ -0,0 +1,372 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package plugin
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/go-vela/vela-k6/models"
+	"github.com/go-vela/vela-k6/report"
+)
+
+const (
+	notifyAlways     = "always"
+	notifyFailure    = "failure"
+	notifyBreach     = "breach"
+	notifyRegression = "regression"
+
+	formatSlack = "slack"
+	formatTeams = "teams"
+	formatJSON  = "json"
+
+	webhookTimeout = 10 * time.Second
+
+	defaultNotifyTemplate = `k6 performance tests {{.Status}} for {{.Repo}}{{if .Branch}} ({{.Branch}}){{end}}{{if .BuildNumber}} build #{{.BuildNumber}}{{end}}
+{{- if .AbortedBy}}
+• aborted by: {{.AbortedBy}}{{end}}
+{{- range .Breached}}
+• threshold breached: {{.}}{{end}}
+{{- range .Regressions}}
+• regression: {{.}}{{end}}`
+)
+
+// notification is the data made available to notification templates.
+type notification struct {
+	Status      string
+	Reason      string
+	Repo        string
+	Branch      string
+	Commit      string
+	BuildNumber string
+	BuildLink   string
+	Script      string
+	Breached    []string
+	Regressions []string
+	AbortedBy   string
+	Summary     *models.Summary
+}
+
+// parseNotifyConfig decodes and validates the JSON value of the 'notify'
+// plugin parameter. A nil config is returned if raw is empty.
+func parseNotifyConfig(raw string) (*models.NotifyConfig, error) {
+	if strings.TrimSpace(raw) == "" {
+		return nil, nil
+	}
+
+	cfg := &models.NotifyConfig{}
+	if err := json.Unmarshal([]byte(raw), cfg); err != nil {
+		return nil, fmt.Errorf("decode: %w", err)
+	}
+
+	if len(cfg.Webhooks) == 0 {
+		return nil, errors.New("at least one webhook must be provided")
+	}
+
+	cfg.NotifyOn = strings.ToLower(cfg.NotifyOn)
+	switch cfg.NotifyOn {
+	case "":
+		cfg.NotifyOn = notifyFailure
+	case notifyAlways, notifyFailure, notifyBreach:
+	case notifyRegression:
+		if cfg.BaselinePath == "" {
+			return nil, errors.New("'baseline_path' is required when 'notify_on' is 'regression'")
+		}
+	default:
+		return nil, fmt.Errorf("unknown 'notify_on' value %q", cfg.NotifyOn)
+	}
+
+	if cfg.RegressionTolerance <= 0 {
+		cfg.RegressionTolerance = report.DefaultTolerance
+	}
+
+	for i := range cfg.Webhooks {
+		webhook := &cfg.Webhooks[i]
+		if webhook.URL == "" && webhook.URLEnv != "" {
+			webhook.URL = os.Getenv(webhook.URLEnv)
+		}
+
+		if webhook.URL == "" {
+			return nil, fmt.Errorf("webhook %d has no 'url'", i)
+		}
+
+		webhook.Format = strings.ToLower(webhook.Format)
+		switch webhook.Format {
+		case "":
+			webhook.Format = formatSlack
+		case formatSlack, formatTeams:
+		case formatJSON:
+			if webhook.Template == "" && cfg.Template == "" {
+				return nil, fmt.Errorf("webhook %d uses the 'json' format but no 'template' is provided", i)
+			}
+		default:
+			return nil, fmt.Errorf("webhook %d has unknown 'format' %q", i, webhook.Format)
+		}
+
+		if _, err := parseTemplate(webhookTemplate(*webhook, cfg.Template)); err != nil {
+			return nil, fmt.Errorf("webhook %d: %w", i, err)
+		}
+	}
+
+	return cfg, nil
+}
+
+// Notify sends the test results to the configured webhooks if the
+// 'notify_on' policy matches the outcome of the run. runErr is the error
+// returned by the setup script or the tests, if any.
+func (p *pluginType) Notify(runErr error) error {
+	cfg := p.config.Notify
+	if cfg == nil {
+		return nil
+	}
+
+	n := p.newNotification(runErr)
+
+	switch cfg.NotifyOn {
+	case notifyFailure:
+		if runErr == nil {
+			return nil
+		}
+	case notifyBreach:
+		if len(n.Breached) == 0 && !p.result.ThresholdsBreached {
+			return nil
+		}
+	case notifyRegression:
+		if len(n.Regressions) == 0 {
+			return nil
+		}
+	}
+
+	var errs []error
+
+	r := p.newRedactor()
+
+	for i, webhook := range cfg.Webhooks {
+		body, err := buildPayload(webhook, cfg.Template, n)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("build payload for webhook %d: %w", i, err))
+			continue
+		}
+
+		body = []byte(r.redact(string(body)))
+
+		if err := postWebhook(webhook.URL, body); err != nil {
+			errs = append(errs, fmt.Errorf("send notification to webhook %d: %s", i, r.redact(err.Error())))
+			continue
+		}
+
+		log.Printf("Notification sent to webhook %d (%s)\n", i, webhook.Format)
+	}
+
+	return errors.Join(errs...)
+}
+
+// newNotification gathers the template data for the outcome of the run.
+func (p *pluginType) newNotification(runErr error) notification {
+	n := notification{
+		Status:      "passed",
+		Repo:        os.Getenv("VELA_REPO_FULL_NAME"),
+		Branch:      os.Getenv("VELA_BUILD_BRANCH"),
+		Commit:      os.Getenv("VELA_BUILD_COMMIT"),
+		BuildNumber: os.Getenv("VELA_BUILD_NUMBER"),
+		BuildLink:   os.Getenv("VELA_BUILD_LINK"),
+		Script:      p.config.ScriptPath,
+		Summary:     p.result.Summary,
+		Breached:    p.result.Summary.BreachedThresholds(),
+		AbortedBy:   p.result.AbortedBy,
+	}
+
+	if p.result.Summary != nil && p.config.Notify.BaselinePath != "" {
+		baseline, err := models.ReadSummary(p.config.Notify.BaselinePath)
+		if err != nil {
+			log.Printf("WARNING: read baseline summary: %s\n", err)
+		} else {
+			n.Regressions = report.Compare(baseline, p.result.Summary, p.config.Notify.RegressionTolerance)
+		}
+	}
+
+	if runErr != nil {
+		n.Reason = runErr.Error()
+	}
+
+	switch {
+	case n.AbortedBy != "":
+		n.Status = "aborted"
+	case len(n.Breached) > 0 || p.result.ThresholdsBreached:
+		n.Status = "breached thresholds"
+	case runErr != nil:
+		n.Status = "failed"
+	case len(n.Regressions) > 0:
+		n.Status = "regressed"
+	}
+
+	return n
+}
+
+// webhookTemplate returns the template used for webhook: its own
+// template, the shared template, or the default template, in that order.
+func webhookTemplate(webhook models.Webhook, shared string) string {
+	if webhook.Template != "" {
+		return webhook.Template
+	}
+
+	if shared != "" {
+		return shared
+	}
+
+	return defaultNotifyTemplate
+}
+
+// parseTemplate parses a notification template. The "json" function is
+// available to templates to safely embed values in JSON payloads.
+func parseTemplate(text string) (*template.Template, error) {
+	return template.New("notify").
+		Funcs(template.FuncMap{
+			"json": func(v any) (string, error) {
+				b, err := json.Marshal(v)
+				return string(b), err
+			},
+		}).
+		Option("missingkey=zero").
+		Parse(text)
+}
+
+// buildPayload renders the request body for webhook.
+func buildPayload(webhook models.Webhook, shared string, n notification) ([]byte, error) {
+	tmpl, err := parseTemplate(webhookTemplate(webhook, shared))
+	if err != nil {
+		return nil, err
+	}
+
+	var rendered bytes.Buffer
+	if err := tmpl.Execute(&rendered, n); err != nil {
+		return nil, fmt.Errorf("render template: %w", err)
+	}
+
+	switch webhook.Format {
+	case formatSlack:
+		return marshalPayload(slackPayload(rendered.String(), n))
+	case formatTeams:
+		return marshalPayload(teamsPayload(rendered.String(), n))
+	default:
+		if !json.Valid(rendered.Bytes()) {
+			return nil, errors.New("rendered template is not valid JSON")
+		}
+
+		return rendered.Bytes(), nil
+	}
+}
+
+// marshalPayload encodes payload as JSON without escaping HTML characters,
+// which chat services render as-is.
+func marshalPayload(payload any) ([]byte, error) {
+	var buf bytes.Buffer
+
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+
+	if err := enc.Encode(payload); err != nil {
+		return nil, err
+	}
+
+	return bytes.TrimSpace(buf.Bytes()), nil
+}
+
+// slackPayload returns a Slack message using Block Kit blocks.
+func slackPayload(message string, n notification) map[string]any {
+	blocks := []map[string]any{
+		{
+			"type": "header",
+			"text": map[string]any{"type": "plain_text", "text": "k6 performance tests " + n.Status},
+		},
+		{
+			"type": "section",
+			"text": map[string]any{"type": "mrkdwn", "text": message},
+		},
+	}
+
+	if n.BuildLink != "" {
+		blocks = append(blocks, map[string]any{
+			"type": "context",
+			"elements": []map[string]any{
+				{"type": "mrkdwn", "text": fmt.Sprintf("<%s|View build>", n.BuildLink)},
+			},
+		})
+	}
+
+	return map[string]any{"text": message, "blocks": blocks}
+}
+
+// teamsPayload returns a Microsoft Teams message wrapping an Adaptive Card.
+func teamsPayload(message string, n notification) map[string]any {
+	body := []map[string]any{
+		{"type": "TextBlock", "text": "k6 performance tests " + n.Status, "weight": "Bolder", "size": "Medium"},
+		{"type": "TextBlock", "text": message, "wrap": true},
+	}
+
+	card := map[string]any{
+		"$schema": "http://adaptivecards.io/schemas/adaptive-card.json",
+		"type":    "AdaptiveCard",
+		"version": "1.4",
+		"body":    body,
+	}
+
+	if n.BuildLink != "" {
+		card["actions"] = []map[string]any{
+			{"type": "Action.OpenUrl", "title": "View build", "url": n.BuildLink},
+		}
+	}
+
+	return map[string]any{
+		"type": "message",
+		"attachments": []map[string]any{
+			{"contentType": "application/vnd.microsoft.card.adaptive", "content": card},
+		},
+	}
+}
+
+// postWebhook sends body to webhookURL as a JSON POST request. Its errors leave
+// out the URL, as webhook URLs embed their credentials.
+func postWebhook(webhookURL string, body []byte) error {
+	ctx, cancel := context.WithTimeout(context.Background(), webhookTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhookURL, bytes.NewReader(body))
+	if err != nil {
+		return withoutURL(err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return withoutURL(err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("unexpected response status %s", resp.Status)
+	}
+
+	return nil
+}
+
+// withoutURL returns err without the URL a *url.Error adds to its message.
+func withoutURL(err error) error {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		return fmt.Errorf("%s request: %w", urlErr.Op, urlErr.Err)
+	}
+
+	return err
+}
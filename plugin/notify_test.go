@@ -0,0 +1,233 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package plugin
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/go-vela/vela-k6/models"
+	"github.com/go-vela/vela-k6/report"
+)
+
+// webhookRecorder returns a test server recording the request bodies it
+// receives, responding with status.
+func webhookRecorder(t *testing.T, status int) (*httptest.Server, *[]string) {
+	t.Helper()
+
+	var bodies []string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, err := io.ReadAll(r.Body)
+		assert.NoError(t, err)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+
+		bodies = append(bodies, string(b))
+
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(server.Close)
+
+	return server, &bodies
+}
+
+func breachedSummary() *models.Summary {
+	return &models.Summary{
+		Metrics: map[string]models.Metric{
+			"http_req_duration": {
+				Values:     map[string]float64{"avg": 100, "p(95)": 300},
+				Thresholds: map[string]bool{"p(95)<200": true},
+			},
+		},
+	}
+}
+
+func TestParseNotifyConfig(t *testing.T) {
+	t.Run("Empty", func(t *testing.T) {
+		cfg, err := parseNotifyConfig("")
+		assert.NoError(t, err)
+		assert.Nil(t, cfg)
+	})
+	t.Run("Defaults", func(t *testing.T) {
+		cfg, err := parseNotifyConfig(`{"webhooks": [{"url": "https://example.com/hook"}]}`)
+		require.NoError(t, err)
+		assert.Equal(t, notifyFailure, cfg.NotifyOn)
+		assert.Equal(t, formatSlack, cfg.Webhooks[0].Format)
+		assert.InDelta(t, report.DefaultTolerance, cfg.RegressionTolerance, 0)
+	})
+	t.Run("URL From Env", func(t *testing.T) {
+		t.Setenv("SLACK_WEBHOOK", "https://example.com/secret")
+
+		cfg, err := parseNotifyConfig(`{"webhooks": [{"url_env": "SLACK_WEBHOOK", "format": "Teams"}], "notify_on": "ALWAYS"}`)
+		require.NoError(t, err)
+		assert.Equal(t, "https://example.com/secret", cfg.Webhooks[0].URL)
+		assert.Equal(t, formatTeams, cfg.Webhooks[0].Format)
+		assert.Equal(t, notifyAlways, cfg.NotifyOn)
+	})
+	t.Run("Invalid", func(t *testing.T) {
+		for name, raw := range map[string]string{
+			"not json":          `webhooks`,
+			"no webhooks":       `{"webhooks": []}`,
+			"no url":            `{"webhooks": [{"format": "slack"}]}`,
+			"unknown policy":    `{"webhooks": [{"url": "https://example.com"}], "notify_on": "sometimes"}`,
+			"no baseline":       `{"webhooks": [{"url": "https://example.com"}], "notify_on": "regression"}`,
+			"unknown format":    `{"webhooks": [{"url": "https://example.com", "format": "irc"}]}`,
+			"json w/o template": `{"webhooks": [{"url": "https://example.com", "format": "json"}]}`,
+			"bad template":      `{"webhooks": [{"url": "https://example.com", "template": "{{.Status"}]}`,
+		} {
+			_, err := parseNotifyConfig(raw)
+			assert.Error(t, err, name)
+		}
+	})
+}
+
+func TestNotify(t *testing.T) {
+	t.Setenv("VELA_REPO_FULL_NAME", "octocat/hello-world")
+	t.Setenv("VELA_BUILD_NUMBER", "42")
+	t.Setenv("VELA_BUILD_LINK", "https://vela.example.com/octocat/hello-world/42")
+
+	t.Run("No Config", func(t *testing.T) {
+		p := &pluginType{}
+		assert.NoError(t, p.Notify(errors.New("some error")))
+	})
+	t.Run("Policy Not Matched", func(t *testing.T) {
+		server, bodies := webhookRecorder(t, http.StatusOK)
+
+		p := &pluginType{config: config{Notify: &models.NotifyConfig{
+			NotifyOn: notifyBreach,
+			Webhooks: []models.Webhook{{URL: server.URL, Format: formatSlack}},
+		}}}
+
+		assert.NoError(t, p.Notify(nil))
+		assert.Empty(t, *bodies)
+	})
+	t.Run("Slack On Breach", func(t *testing.T) {
+		server, bodies := webhookRecorder(t, http.StatusOK)
+
+		p := &pluginType{
+			config: config{Notify: &models.NotifyConfig{
+				NotifyOn: notifyBreach,
+				Webhooks: []models.Webhook{{URL: server.URL, Format: formatSlack}},
+			}},
+			result: runResult{ThresholdsBreached: true, Summary: breachedSummary()},
+		}
+
+		require.NoError(t, p.Notify(nil))
+		require.Len(t, *bodies, 1)
+
+		var payload map[string]any
+		require.NoError(t, json.Unmarshal([]byte((*bodies)[0]), &payload))
+		assert.Contains(t, payload["text"], "breached thresholds for octocat/hello-world build #42")
+		assert.Contains(t, payload["text"], "http_req_duration: p(95)<200")
+		assert.Len(t, payload["blocks"], 3)
+	})
+	t.Run("Teams On Failure", func(t *testing.T) {
+		server, bodies := webhookRecorder(t, http.StatusOK)
+
+		p := &pluginType{config: config{Notify: &models.NotifyConfig{
+			NotifyOn: notifyFailure,
+			Webhooks: []models.Webhook{{URL: server.URL, Format: formatTeams}},
+		}}}
+
+		require.NoError(t, p.Notify(errors.New("run setup script: exit status 1")))
+		require.Len(t, *bodies, 1)
+		assert.Contains(t, (*bodies)[0], "application/vnd.microsoft.card.adaptive")
+		assert.Contains(t, (*bodies)[0], "k6 performance tests failed")
+	})
+	t.Run("Slack On Abort", func(t *testing.T) {
+		server, bodies := webhookRecorder(t, http.StatusOK)
+
+		p := &pluginType{
+			config: config{Notify: &models.NotifyConfig{
+				NotifyOn: notifyFailure,
+				Webhooks: []models.Webhook{{URL: server.URL, Format: formatSlack}},
+			}},
+			result: runResult{AbortedBy: "http_req_failed rate > 0.2 for 60s"},
+		}
+
+		require.NoError(t, p.Notify(errors.New("run aborted")))
+		require.Len(t, *bodies, 1)
+		assert.Contains(t, (*bodies)[0], "k6 performance tests aborted")
+		assert.Contains(t, (*bodies)[0], "aborted by: http_req_failed rate > 0.2 for 60s")
+	})
+	t.Run("JSON Template", func(t *testing.T) {
+		server, bodies := webhookRecorder(t, http.StatusOK)
+
+		p := &pluginType{config: config{Notify: &models.NotifyConfig{
+			NotifyOn: notifyAlways,
+			Template: `{"status": {{json .Status}}, "repo": {{json .Repo}}}`,
+			Webhooks: []models.Webhook{{URL: server.URL, Format: formatJSON}},
+		}}}
+
+		require.NoError(t, p.Notify(nil))
+		require.Len(t, *bodies, 1)
+		assert.JSONEq(t, `{"status": "passed", "repo": "octocat/hello-world"}`, (*bodies)[0])
+	})
+	t.Run("Invalid JSON Template Output", func(t *testing.T) {
+		server, bodies := webhookRecorder(t, http.StatusOK)
+
+		p := &pluginType{config: config{Notify: &models.NotifyConfig{
+			NotifyOn: notifyAlways,
+			Template: `{"status": {{.Status}}}`,
+			Webhooks: []models.Webhook{{URL: server.URL, Format: formatJSON}},
+		}}}
+
+		assert.ErrorContains(t, p.Notify(nil), "not valid JSON")
+		assert.Empty(t, *bodies)
+	})
+	t.Run("Webhook Error Status", func(t *testing.T) {
+		server, _ := webhookRecorder(t, http.StatusInternalServerError)
+
+		p := &pluginType{config: config{Notify: &models.NotifyConfig{
+			NotifyOn: notifyAlways,
+			Webhooks: []models.Webhook{{URL: server.URL, Format: formatSlack}},
+		}}}
+
+		assert.ErrorContains(t, p.Notify(nil), "unexpected response status 500")
+	})
+	t.Run("Webhook Unreachable", func(t *testing.T) {
+		server, _ := webhookRecorder(t, http.StatusOK)
+		server.Close()
+
+		webhook := server.URL + "/services/T000/B000/s3cr3t"
+
+		p := &pluginType{config: config{Notify: &models.NotifyConfig{
+			NotifyOn: notifyAlways,
+			Webhooks: []models.Webhook{{URL: webhook, Format: formatSlack}},
+		}}}
+
+		err := p.Notify(nil)
+		assert.ErrorContains(t, err, "send notification to webhook 0: Post request")
+		assert.NotContains(t, err.Error(), "s3cr3t")
+		assert.NotContains(t, err.Error(), server.URL)
+	})
+	t.Run("Regression", func(t *testing.T) {
+		server, bodies := webhookRecorder(t, http.StatusOK)
+
+		baseline := filepath.Join(t.TempDir(), "baseline.json")
+		require.NoError(t, os.WriteFile(baseline, []byte(`{"metrics": {"http_req_duration": {"avg": 100, "p(95)": 200}}}`), 0600))
+
+		p := &pluginType{
+			config: config{Notify: &models.NotifyConfig{
+				NotifyOn:            notifyRegression,
+				BaselinePath:        baseline,
+				RegressionTolerance: 10,
+				Webhooks:            []models.Webhook{{URL: server.URL, Format: formatSlack}},
+			}},
+			result: runResult{Summary: breachedSummary()},
+		}
+
+		require.NoError(t, p.Notify(nil))
+		require.Len(t, *bodies, 1)
+		assert.Contains(t, (*bodies)[0], "regression: http_req_duration p(95) 200.00 -> 300.00 (+50.0%)")
+	})
+}
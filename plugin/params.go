@@ -0,0 +1,705 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package plugin
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-vela/vela-k6/k6api"
+	"github.com/go-vela/vela-k6/models"
+)
+
+// outputNamePattern matches the name of a k6 output, such as "json" or
+// "experimental-prometheus-rw".
+var outputNamePattern = regexp.MustCompile(`^[a-z][a-z0-9-]*$`)
+
+// paramType is the type a parameter value is parsed as.
+type paramType string
+
+const (
+	typeString   paramType = "string"
+	typeBool     paramType = "bool"
+	typeInt      paramType = "int"
+	typeDuration paramType = "duration"
+	typeList     paramType = "list"
+	typeMap      paramType = "map"
+	typeJSON     paramType = "json"
+	typePath     paramType = "path"
+	typeDir      paramType = "dir"
+)
+
+// duration is a time.Duration written as a string, such as "30s", in the
+// effective configuration.
+type duration time.Duration
+
+// MarshalJSON encodes the duration as a string.
+func (d duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// parameter declares a single Vela plugin parameter. The parameter is read
+// from the PARAMETER_<NAME> environment variable, parsed according to its
+// type, validated, and stored in the plugin configuration.
+type parameter struct {
+	// Name is the name of the parameter in the Vela pipeline.
+	Name string
+	// Type is the type the value is parsed as.
+	Type paramType
+	// Default is the raw value used when the parameter is not set.
+	Default string
+	// Required reports an error when the parameter is not set.
+	Required bool
+	// Extensions are the file extensions accepted by path parameters, or
+	// nil to accept any file.
+	Extensions []string
+	// Enum lists the accepted values of string parameters, in any case.
+	Enum []string
+	// Schema is the JSON Schema of json parameters in the plan file.
+	Schema map[string]any
+	// Validate optionally checks the parsed value.
+	Validate func(value any) error
+	// Set stores the parsed value in the configuration. It may return an
+	// error if the value can't be converted.
+	Set func(cfg *config, value any) error
+	// Description documents the parameter in DOCS.md.
+	Description string
+}
+
+// envName returns the environment variable the parameter is read from.
+func (param parameter) envName() string {
+	return "PARAMETER_" + strings.ToUpper(param.Name)
+}
+
+// parameters is the schema of every plugin parameter, in the order they
+// are documented.
+var parameters = []parameter{
+	{
+		Name:        "script_path",
+		Type:        typePath,
+		Required:    true,
+		Extensions:  scriptExtensions,
+		Set:         func(cfg *config, v any) error { cfg.ScriptPath = v.(string); return nil },
+		Description: "path to the k6 script file or [k6 archive](https://grafana.com/docs/k6/latest/misc/archive/). must be a JavaScript or TypeScript file (`.js`, `.mjs`, `.cjs` or `.ts`) or a `.tar` archive containing `metadata.json`.",
+	},
+	{
+		Name:        "output_path",
+		Type:        typePath,
+		Extensions:  jsonExtensions,
+		Set:         func(cfg *config, v any) error { cfg.OutputPath = v.(string); return nil },
+		Description: "path to the output file that will be created. directories will be created as necessary. if empty, no output file will be generated. must be a `.json` file.",
+	},
+	{
+		Name:        "setup_script_path",
+		Type:        typePath,
+		Extensions:  shellExtensions,
+		Set:         func(cfg *config, v any) error { cfg.SetupScriptPath = v.(string); return nil },
+		Description: "path to an optional setup script file to be run before tests. must be a shell script (sh or bash) with execute permissions and the `.sh` extension.",
+	},
+	{
+		Name:        "fail_on_threshold_breach",
+		Type:        typeBool,
+		Default:     "true",
+		Set:         func(cfg *config, v any) error { cfg.FailOnThresholdBreach = v.(bool); return nil },
+		Description: "if `false`, the pipeline step will not fail even if thresholds are breached.",
+	},
+	{
+		Name:        "projektor_compat_mode",
+		Type:        typeBool,
+		Default:     "false",
+		Set:         func(cfg *config, v any) error { cfg.ProjektorCompatMode = v.(bool); return nil },
+		Description: "if `true`, output will be generated with the `--summary-export` flag instead of the `--out` flag. this is necessary for results uploaded to a [Projektor](https://projektor.dev/) server.",
+	},
+	{
+		Name:        "log_progress",
+		Type:        typeBool,
+		Default:     "false",
+		Set:         func(cfg *config, v any) error { cfg.LogProgress = v.(bool); return nil },
+		Description: "if `true`, k6 progress bar output will print to the Vela pipeline. Not recommended for numerous or long-running tests, as logging becomes excessive.",
+	},
+	{
+		Name:        "notify",
+		Type:        typeJSON,
+		Schema:      notifySchema,
+		Set:         setNotify,
+		Description: "webhook notifications sent after the run. see the `notify` keys above.",
+	},
+	{
+		Name:        "require_thresholds",
+		Type:        typeBool,
+		Default:     "false",
+		Set:         func(cfg *config, v any) error { cfg.RequireThresholds = v.(bool); return nil },
+		Description: "if `true`, the step fails before running the setup script when the script defines no thresholds.",
+	},
+	{
+		Name:        "dry_run",
+		Type:        typeBool,
+		Default:     "false",
+		Set:         func(cfg *config, v any) error { cfg.DryRun = v.(bool); return nil },
+		Description: "if `true`, the effective configuration and the commands that would be executed are printed, and neither the setup script nor k6 is run.",
+	},
+	{
+		Name:        "quiet",
+		Type:        typeBool,
+		Default:     "false",
+		Set:         func(cfg *config, v any) error { cfg.Quiet = v.(bool); return nil },
+		Description: "if `true`, the startup line with the version of the plugin is not logged.",
+	},
+	{
+		Name:        "archive_output_path",
+		Type:        typePath,
+		Extensions:  archiveExtensions,
+		Set:         func(cfg *config, v any) error { cfg.ArchiveOutputPath = v.(string); return nil },
+		Description: "path to a `.tar` file the script is bundled into with `k6 archive` before the run. the tests are then run from this bundle, so it can be stored as a build artifact.",
+	},
+	{
+		Name:        "compatibility_mode",
+		Type:        typeString,
+		Enum:        compatibilityModes,
+		Set:         func(cfg *config, v any) error { cfg.CompatibilityMode = strings.ToLower(v.(string)); return nil },
+		Description: "JavaScript compatibility mode passed to k6 as `--compatibility-mode` when inspecting, archiving and running the script. one of `base`, `extended` or `experimental_enhanced`.",
+	},
+	{
+		Name:        "config_path",
+		Type:        typePath,
+		Extensions:  planExtensions,
+		Set:         func(cfg *config, v any) error { cfg.ConfigPath = v.(string); return nil },
+		Description: "path to a YAML or JSON test plan file. its keys are the parameters in this table, plus per-script settings under `scripts`. parameters set in the step override the plan. see the test plan section above.",
+	},
+	{
+		Name:        "env",
+		Type:        typeMap,
+		Set:         func(cfg *config, v any) error { cfg.Env = v.(map[string]string); return nil },
+		Description: "environment variables passed to the script with `-e`, available in `__ENV`.",
+	},
+	{
+		Name:        "secret_env",
+		Type:        typeList,
+		Set:         func(cfg *config, v any) error { cfg.SecretEnv = v.([]string); return nil },
+		Description: "names of `env` entries and of step environment variables, such as Vela secrets, whose values are masked in the plugin and k6 output. the values of variables with a name part such as `TOKEN`, `SECRET`, `PASSWORD`, `API_KEY` or `WEBHOOK`, as in `API_TOKEN`, are masked as well, unless they are numbers or shorter than 6 characters.",
+	},
+	{
+		Name:        "redact_patterns",
+		Type:        typeList,
+		Validate:    validateRedactPatterns,
+		Set:         func(cfg *config, v any) error { cfg.RedactPatterns = v.([]string); return nil },
+		Description: "regular expressions masked in the plugin and k6 output, in addition to the credentials of `Authorization` headers and bearer tokens. only the capture groups of a pattern are masked, or its whole match if it has none. patterns with commas must be given as a JSON array.",
+	},
+	{
+		Name:        "tags",
+		Type:        typeMap,
+		Set:         func(cfg *config, v any) error { cfg.Tags = v.(map[string]string); return nil },
+		Description: "tags added to every metric with `--tag`.",
+	},
+	{
+		Name:        "outputs",
+		Type:        typeList,
+		Validate:    validateOutputs,
+		Set:         func(cfg *config, v any) error { cfg.Outputs = v.([]string); return nil },
+		Description: "additional k6 outputs passed with `--out`, such as `csv=results.csv` or `experimental-prometheus-rw`.",
+	},
+	{
+		Name:        "thresholds",
+		Type:        typeJSON,
+		Schema:      thresholdsSchema,
+		Set:         setThresholds,
+		Description: "thresholds passed to k6 in a generated config file with `--config`, as a map of metric to a list of expressions or `{threshold, abortOnFail, delayAbortEval}` objects.",
+	},
+	{
+		Name:        "gates",
+		Type:        typeJSON,
+		Schema:      gatesSchema,
+		Set:         setGates,
+		Description: "quality gates checked against the end-of-test summary, as a list of `{metric, stat, max, min}` objects. the step fails if any gate is not met.",
+	},
+	{
+		Name:        "thresholds_mode",
+		Type:        typeString,
+		Default:     thresholdsModeMerge,
+		Enum:        thresholdsModes,
+		Set:         func(cfg *config, v any) error { cfg.ThresholdsMode = strings.ToLower(v.(string)); return nil },
+		Description: "how `thresholds` are combined with the thresholds defined in the script `options`. `merge` replaces the script thresholds of the same metrics and keeps the others, `replace` drops every script threshold.",
+	},
+	{
+		Name:        "profile",
+		Type:        typeString,
+		Set:         func(cfg *config, v any) error { cfg.Profile = v.(string); return nil },
+		Description: "name of the load profile to run the script with. one of the built-in `smoke`, `load`, `stress`, `spike` and `soak` profiles or a profile defined in `profiles`. see the load profiles section above.",
+	},
+	{
+		Name:        "profiles",
+		Type:        typeJSON,
+		Schema:      profilesSchema,
+		Set:         setProfiles,
+		Description: "load profiles, as a map of name to `{vus, duration, stages, thresholds, env, outputs}` objects. profiles replace built-in profiles of the same name.",
+	},
+	{
+		Name:        "k6_config_path",
+		Type:        typePath,
+		Extensions:  jsonExtensions,
+		Set:         setK6ConfigPath,
+		Description: "path to a [k6 config file](https://grafana.com/docs/k6/latest/using-k6/k6-options/how-to/#config-file) passed with `--config`. when `thresholds` are set, its options are copied into the generated config file instead.",
+	},
+	{
+		Name:        "extra_args",
+		Type:        typeList,
+		Validate:    validateExtraArgs,
+		Set:         func(cfg *config, v any) error { cfg.ExtraArgs = v.([]string); return nil },
+		Description: "additional `k6 run` flags, such as `--http-debug` or `--batch=20`. only known flags not set by the plugin are accepted. see the extra arguments section above.",
+	},
+	{
+		Name:        "summary_trend_stats",
+		Type:        typeList,
+		Validate:    validateTrendStats,
+		Set:         func(cfg *config, v any) error { cfg.SummaryTrendStats = v.([]string); return nil },
+		Description: "trend stats shown in the end-of-test summary and summary export, passed with `--summary-trend-stats`, such as `avg,p(95),p(99.9)`. with `projektor_compat_mode`, the stats Projektor requires are always added.",
+	},
+	{
+		Name:        "summary_time_unit",
+		Type:        typeString,
+		Enum:        summaryTimeUnits,
+		Set:         func(cfg *config, v any) error { cfg.SummaryTimeUnit = strings.ToLower(v.(string)); return nil },
+		Description: "time unit of the end-of-test summary, passed with `--summary-time-unit`. one of `s`, `ms` or `us`.",
+	},
+	{
+		Name:        "no_summary",
+		Type:        typeBool,
+		Default:     "false",
+		Set:         func(cfg *config, v any) error { cfg.NoSummary = v.(bool); return nil },
+		Description: "if `true`, the end-of-test summary is disabled with `--no-summary`. can't be combined with `projektor_compat_mode`, `notify` or `gates`.",
+	},
+	{
+		Name:        "console_log_path",
+		Type:        typePath,
+		Extensions:  logExtensions,
+		Set:         func(cfg *config, v any) error { cfg.ConsoleLogPath = v.(string); return nil },
+		Description: "path to a `.log` or `.txt` file the raw k6 output is written to, in addition to the step log. directories will be created as necessary.",
+	},
+	{
+		Name:        "console_log_mode",
+		Type:        typeString,
+		Default:     consoleLogModeCombined,
+		Enum:        consoleLogModes,
+		Set:         func(cfg *config, v any) error { cfg.ConsoleLogMode = strings.ToLower(v.(string)); return nil },
+		Description: "how stdout and stderr are written to `console_log_path`. `combined` writes both to the file with a `[stdout]` or `[stderr]` marker on each line, `separate` writes them to `<name>.stdout.log` and `<name>.stderr.log`.",
+	},
+	{
+		Name:        "log_tail_lines",
+		Type:        typeInt,
+		Default:     "0",
+		Validate:    validateNonNegative,
+		Set:         func(cfg *config, v any) error { cfg.LogTailLines = v.(int); return nil },
+		Description: "if greater than `0` and `log_progress` is `true`, only the last lines of k6 output are printed to the step log, once the run ends. the full output can be kept with `console_log_path`.",
+	},
+	{
+		Name:        "max_script_errors",
+		Type:        typeInt,
+		Validate:    validateNonNegative,
+		Set:         func(cfg *config, v any) error { n := v.(int); cfg.MaxScriptErrors = &n; return nil },
+		Description: "if set, the step fails when k6 logs more error-level messages, such as `console.error` calls in the script. `0` fails on any error.",
+	},
+	{
+		Name:        "progress_interval",
+		Type:        typeDuration,
+		Validate:    validateProgressInterval,
+		Set:         func(cfg *config, v any) error { cfg.ProgressInterval = duration(v.(time.Duration)); return nil },
+		Description: "if set, such as `1m`, a status line with the elapsed time, VUs, iterations, requests per second, error rate and p(95) of `http_req_duration` is printed at this interval, computed by the plugin from the k6 JSON output. works independently of `log_progress`.",
+	},
+	{
+		Name:        "abort_conditions",
+		Type:        typeList,
+		Set:         setAbortConditions,
+		Description: "conditions evaluated by the plugin on the k6 JSON output during the run, such as `http_req_failed rate > 0.2 for 60s`. k6 is stopped gracefully as soon as one is true, and the step fails. see the abort conditions section above.",
+	},
+	{
+		Name:        "api_address",
+		Type:        typeString,
+		Default:     k6api.DefaultAddress,
+		Validate:    validateAPIAddress,
+		Set:         func(cfg *config, v any) error { cfg.APIAddress = v.(string); return nil },
+		Description: "loopback address k6 serves its [REST API](https://grafana.com/docs/k6/latest/reference/k6-rest-api/) on, passed with `--address`. the plugin uses it to poll the test status and metrics and to stop the test gracefully.",
+	},
+	{
+		Name:        "linger",
+		Type:        typeBool,
+		Default:     "false",
+		Set:         func(cfg *config, v any) error { cfg.Linger = v.(bool); return nil },
+		Description: "if `true`, k6 is run with `--linger` so its REST API outlives the test: the final metrics are read from the API once the test has ended, such as with `no_summary`, and k6 is then stopped through it, or interrupted if it hasn't exited within 5 seconds. can't be combined with `instances`.",
+	},
+	{
+		Name:        "instances",
+		Type:        typeInt,
+		Default:     "1",
+		Validate:    validateInstances,
+		Set:         func(cfg *config, v any) error { cfg.Instances = v.(int); return nil },
+		Description: "number of k6 processes the test is split across with execution segments, run concurrently in the step container. their JSON outputs are merged, and the thresholds are evaluated on the merged results. see the distributed runs section above.",
+	},
+	{
+		Name:        "min_k6_version",
+		Type:        typeString,
+		Validate:    validateSemver,
+		Set:         func(cfg *config, v any) error { cfg.MinK6Version = v.(string); return nil },
+		Description: "oldest k6 version the step runs with, such as `v1.0.0`. the version reported by `k6 version` is checked against it, and against the features the configuration uses, before the script is inspected.",
+	},
+	{
+		Name:        "k6_binary_path",
+		Type:        typePath,
+		Validate:    validateExecutable,
+		Set:         func(cfg *config, v any) error { cfg.K6BinaryPath = v.(string); return nil },
+		Description: "path to the k6 binary the plugin runs instead of the bundled `k6`, such as one built with [xk6](https://github.com/grafana/xk6) and committed to the repository. must be an executable file.",
+	},
+	{
+		Name:        "k6_extensions",
+		Type:        typeList,
+		Set:         func(cfg *config, v any) error { cfg.K6Extensions = v.([]string); return nil },
+		Description: "extensions the k6 binary must be built with, by name such as `k6/x/sql` or by module such as `github.com/grafana/xk6-sql`. checked against the extensions reported by `k6 version` before the script is inspected.",
+	},
+	{
+		Name:        "browser",
+		Type:        typeBool,
+		Default:     "false",
+		Set:         func(cfg *config, v any) error { cfg.Browser = v.(bool); return nil },
+		Description: "if `true`, the script runs browser tests with the [k6 browser module](https://grafana.com/docs/k6/latest/using-k6-browser/). Chromium must be available in the image, and the Web Vitals of the run are logged. see the browser tests section above.",
+	},
+	{
+		Name:        "browser_headless",
+		Type:        typeBool,
+		Default:     "true",
+		Set:         func(cfg *config, v any) error { cfg.BrowserHeadless = v.(bool); return nil },
+		Description: "if `false`, the browser runs with a window. passed to k6 as `K6_BROWSER_HEADLESS`.",
+	},
+	{
+		Name:        "browser_args",
+		Type:        typeList,
+		Default:     "no-sandbox",
+		Set:         func(cfg *config, v any) error { cfg.BrowserArgs = v.([]string); return nil },
+		Description: "command line arguments of the browser, without the leading dashes, passed to k6 as `K6_BROWSER_ARGS`. the default disables the sandbox, which Chromium can't use in most containers.",
+	},
+	{
+		Name:        "browser_timeout",
+		Type:        typeDuration,
+		Set:         func(cfg *config, v any) error { cfg.BrowserTimeout = duration(v.(time.Duration)); return nil },
+		Description: "default timeout of browser actions, such as `1m`, passed to k6 as `K6_BROWSER_TIMEOUT`. k6 uses `30s` when it is not set.",
+	},
+	{
+		Name:        "browser_executable_path",
+		Type:        typeString,
+		Validate:    validateExecutable,
+		Set:         func(cfg *config, v any) error { cfg.BrowserExecutablePath = v.(string); return nil },
+		Description: "path to the Chromium binary in the image, passed to k6 as `K6_BROWSER_EXECUTABLE_PATH`. by default, Chromium is looked up on the `PATH`.",
+	},
+	{
+		Name:        "screenshots_path",
+		Type:        typeDir,
+		Set:         func(cfg *config, v any) error { cfg.ScreenshotsPath = v.(string); return nil },
+		Description: "directory the screenshots written by the script during the run are moved to after the run, so they can be stored as build artifacts. `.png` and `.jpeg` files created in the workspace are collected, keeping their paths.",
+	},
+}
+
+// setNotify decodes and stores the 'notify' parameter.
+func setNotify(cfg *config, v any) error {
+	notify, err := parseNotifyConfig(string(v.(json.RawMessage)))
+	if err != nil {
+		return err
+	}
+
+	cfg.Notify = notify
+
+	return nil
+}
+
+// setThresholds decodes and stores the 'thresholds' parameter.
+func setThresholds(cfg *config, v any) error {
+	var thresholds map[string][]models.Threshold
+	if err := json.Unmarshal(v.(json.RawMessage), &thresholds); err != nil {
+		return fmt.Errorf("decode: %w", err)
+	}
+
+	if err := validateThresholds(thresholds); err != nil {
+		return err
+	}
+
+	cfg.Thresholds = thresholds
+
+	return nil
+}
+
+// setGates decodes and stores the 'gates' parameter.
+func setGates(cfg *config, v any) error {
+	var gates []models.Gate
+	if err := json.Unmarshal(v.(json.RawMessage), &gates); err != nil {
+		return fmt.Errorf("decode: %w", err)
+	}
+
+	for i, gate := range gates {
+		if gate.Metric == "" || gate.Stat == "" {
+			return fmt.Errorf("gate %d must have a metric and a stat", i)
+		}
+
+		if gate.Max == nil && gate.Min == nil {
+			return fmt.Errorf("gate %d (%s %s) must have a max or a min", i, gate.Metric, gate.Stat)
+		}
+	}
+
+	cfg.Gates = gates
+
+	return nil
+}
+
+// validateOutputs checks that every k6 output is of the form type or
+// type=argument.
+func validateOutputs(v any) error {
+	for i, output := range v.([]string) {
+		name, _, _ := strings.Cut(output, "=")
+		if !outputNamePattern.MatchString(name) {
+			return fmt.Errorf("entry %d is not a k6 output (use type or type=argument)", i)
+		}
+	}
+
+	return nil
+}
+
+// validateNonNegative checks that an int parameter is not negative.
+func validateNonNegative(v any) error {
+	if v.(int) < 0 {
+		return fmt.Errorf("%d must not be negative", v.(int))
+	}
+
+	return nil
+}
+
+// parseParameters parses every parameter in the schema into cfg, reading
+// the raw values with lookup. All invalid parameters are reported at once.
+func parseParameters(cfg *config, ws workspace, lookup func(string) string) error {
+	var errs []error
+
+	for _, param := range parameters {
+		if err := parseParameter(cfg, ws, lookup, param); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// parseParameter sets the value of param in cfg, read with lookup, or its
+// default.
+func parseParameter(cfg *config, ws workspace, lookup func(string) string, param parameter) error {
+	raw := strings.TrimSpace(lookup(param.envName()))
+	if raw == "" {
+		raw = param.Default
+	}
+
+	if raw == "" {
+		if param.Required {
+			return fmt.Errorf("'%s' is required: %s", param.Name, param.Description)
+		}
+
+		return nil
+	}
+
+	value, err := param.parse(raw, ws)
+	if err == nil && param.Validate != nil {
+		err = param.Validate(value)
+	}
+
+	if err == nil {
+		err = param.Set(cfg, value)
+	}
+
+	if err != nil {
+		return fmt.Errorf("'%s': %w", param.Name, err)
+	}
+
+	return nil
+}
+
+// parse converts the raw value according to the parameter type. Its
+// errors name the expected form only, as the value may hold a secret.
+func (param parameter) parse(raw string, ws workspace) (any, error) {
+	switch param.Type {
+	case typeBool:
+		return parseBool(raw)
+	case typeString:
+		if len(param.Enum) > 0 && !slices.Contains(param.Enum, strings.ToLower(raw)) {
+			return nil, fmt.Errorf("value must be one of: %s", strings.Join(param.Enum, ", "))
+		}
+
+		return raw, nil
+	case typeInt:
+		i, err := strconv.Atoi(raw)
+		if err != nil {
+			return nil, errors.New("value is not an integer")
+		}
+
+		return i, nil
+	case typeDuration:
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return nil, errors.New("value is not a duration (e.g. \"30s\" or \"5m\")")
+		}
+
+		return d, nil
+	case typeList:
+		return parseList(raw)
+	case typeMap:
+		return parseMap(raw)
+	case typeJSON:
+		if !json.Valid([]byte(raw)) {
+			return nil, errors.New("value is not valid JSON")
+		}
+
+		return json.RawMessage(raw), nil
+	case typePath:
+		return ws.resolvePath(raw, param.Extensions)
+	case typeDir:
+		return ws.resolveDir(raw)
+	default:
+		return raw, nil
+	}
+}
+
+// parseBool parses a boolean, accepting true/false, yes/no, on/off and
+// 1/0 in any case. Any other value is an error rather than a silent
+// default.
+func parseBool(raw string) (bool, error) {
+	switch strings.ToLower(raw) {
+	case "true", "yes", "on", "1":
+		return true, nil
+	case "false", "no", "off", "0":
+		return false, nil
+	default:
+		return false, errors.New("value is not a boolean (use true or false)")
+	}
+}
+
+// parseList parses a JSON array of strings, or a comma separated list as
+// Vela passes YAML sequences.
+func parseList(raw string) ([]string, error) {
+	if strings.HasPrefix(raw, "[") {
+		var list []string
+		if err := json.Unmarshal([]byte(raw), &list); err != nil {
+			return nil, fmt.Errorf("value is not a list of strings: %w", err)
+		}
+
+		return list, nil
+	}
+
+	var list []string
+
+	for item := range strings.SplitSeq(raw, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+
+	return list, nil
+}
+
+// parseMap parses a JSON object with string values, as Vela passes YAML
+// mappings, or a comma separated list of key=value pairs. Its errors don't
+// echo the value, which may hold secrets like webhook URLs or tokens.
+func parseMap(raw string) (map[string]string, error) {
+	if strings.HasPrefix(raw, "{") {
+		var obj map[string]any
+		if err := json.Unmarshal([]byte(raw), &obj); err != nil {
+			return nil, fmt.Errorf("value is not a map: %w", err)
+		}
+
+		m := make(map[string]string, len(obj))
+
+		for key, value := range obj {
+			switch v := value.(type) {
+			case string:
+				m[key] = v
+			case nil:
+				m[key] = ""
+			default:
+				b, _ := json.Marshal(v)
+				m[key] = string(b)
+			}
+		}
+
+		return m, nil
+	}
+
+	m := map[string]string{}
+
+	for pair := range strings.SplitSeq(raw, ",") {
+		if pair = strings.TrimSpace(pair); pair == "" {
+			continue
+		}
+
+		key, value, ok := strings.Cut(pair, "=")
+		if !ok || strings.TrimSpace(key) == "" {
+			return nil, errors.New("value is not a list of key=value pairs")
+		}
+
+		m[strings.TrimSpace(key)] = strings.TrimSpace(value)
+	}
+
+	return m, nil
+}
+
+// parameterTable returns the markdown table documenting every parameter
+// in the schema, as included in DOCS.md.
+func parameterTable() string {
+	rows := [][]string{{"Name", "Type", "Description", "Required", "Default"}}
+
+	for _, param := range parameters {
+		def := "`N/A`"
+		if param.Default != "" {
+			def = fmt.Sprintf("`%s`", param.Default)
+		}
+
+		rows = append(rows, []string{
+			fmt.Sprintf("`%s`", param.Name),
+			fmt.Sprintf("`%s`", param.Type),
+			param.Description,
+			fmt.Sprintf("`%t`", param.Required),
+			def,
+		})
+	}
+
+	widths := make([]int, len(rows[0]))
+
+	for _, row := range rows {
+		for i, cell := range row {
+			widths[i] = max(widths[i], len(cell))
+		}
+	}
+
+	var b strings.Builder
+
+	for i, row := range rows {
+		writeTableRow(&b, row, widths)
+
+		if i == 0 {
+			separator := make([]string, len(widths))
+			for j, width := range widths {
+				separator[j] = strings.Repeat("-", width)
+			}
+
+			writeTableRow(&b, separator, widths)
+		}
+	}
+
+	return b.String()
+}
+
+// writeTableRow writes a markdown table row with cells padded to widths.
+func writeTableRow(b *strings.Builder, cells []string, widths []int) {
+	b.WriteString("|")
+
+	for i, cell := range cells {
+		fmt.Fprintf(b, " %-*s |", widths[i], cell)
+	}
+
+	b.WriteString("\n")
+}
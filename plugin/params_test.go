@@ -0,0 +1,186 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package plugin
+
+import (
+	"encoding/json"
+	"flag"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var updateDocs = flag.Bool("update-docs", false, "regenerate the parameter table in DOCS.md")
+
+const (
+	docsPath        = "../DOCS.md"
+	docsTableStart  = "<!-- parameters:start -->\n"
+	docsTableFinish = "<!-- parameters:end -->"
+)
+
+func TestParseBool(t *testing.T) {
+	for _, raw := range []string{"true", "TRUE", "yes", "On", "1"} {
+		b, err := parseBool(raw)
+		assert.NoError(t, err, raw)
+		assert.True(t, b, raw)
+	}
+
+	for _, raw := range []string{"false", "False", "no", "OFF", "0"} {
+		b, err := parseBool(raw)
+		assert.NoError(t, err, raw)
+		assert.False(t, b, raw)
+	}
+
+	_, err := parseBool("maybe")
+	assert.EqualError(t, err, "value is not a boolean (use true or false)")
+}
+
+func TestParseList(t *testing.T) {
+	list, err := parseList("a, b,,c ")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a", "b", "c"}, list)
+
+	list, err = parseList(`["--http-debug", "a,b"]`)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"--http-debug", "a,b"}, list)
+
+	_, err = parseList(`[1, 2]`)
+	assert.ErrorContains(t, err, "is not a list of strings")
+}
+
+func TestParseMap(t *testing.T) {
+	m, err := parseMap(`{"BASE_URL": "https://example.com", "VUS": 10, "EMPTY": null, "ON": true}`)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"BASE_URL": "https://example.com", "VUS": "10", "EMPTY": "", "ON": "true"}, m)
+
+	m, err = parseMap("team=perf, env = staging")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"team": "perf", "env": "staging"}, m)
+
+	_, err = parseMap("team, s3cr3t")
+	assert.ErrorContains(t, err, "is not a list of key=value pairs")
+	assert.NotContains(t, err.Error(), "s3cr3t")
+
+	_, err = parseMap(`{"token": "s3cr3t", "a": }`)
+	assert.ErrorContains(t, err, "is not a map")
+	assert.NotContains(t, err.Error(), "s3cr3t")
+}
+
+func TestParameterParse(t *testing.T) {
+	ws := workspace{root: "/", real: "/"}
+
+	for _, tc := range []struct {
+		typ      paramType
+		raw      string
+		expected any
+		err      string
+	}{
+		{typ: typeString, raw: "base", expected: "base"},
+		{typ: typeInt, raw: "42", expected: 42},
+		{typ: typeInt, raw: "4.2", err: "is not an integer"},
+		{typ: typeDuration, raw: "1m30s", expected: 90 * time.Second},
+		{typ: typeDuration, raw: "90", err: "is not a duration"},
+		{typ: typeJSON, raw: `{"a": 1}`, expected: json.RawMessage(`{"a": 1}`)},
+		{typ: typeJSON, raw: `{"a": }`, err: "is not valid JSON"},
+		{typ: typeBool, raw: "s3cr3t", err: "is not a boolean"},
+		{typ: typePath, raw: "/tmp/script.png", err: "must have one of the extensions"},
+		{typ: typePath, raw: "/tmp/s3cr3t\n.js", err: "contains control characters"},
+	} {
+		value, err := parameter{Name: "test", Type: tc.typ, Extensions: scriptExtensions}.parse(tc.raw, ws)
+		if tc.err != "" {
+			assert.ErrorContains(t, err, tc.err, tc.raw)
+			// values may hold secrets and are never echoed
+			assert.NotContains(t, err.Error(), tc.raw, tc.raw)
+			continue
+		}
+
+		assert.NoError(t, err, tc.raw)
+		assert.Equal(t, tc.expected, value, tc.raw)
+	}
+}
+
+func TestParseParameters(t *testing.T) {
+	ws, err := newWorkspace()
+	require.NoError(t, err)
+
+	t.Run("Defaults", func(t *testing.T) {
+		env := map[string]string{"PARAMETER_SCRIPT_PATH": "./test/script.js"}
+
+		cfg := config{}
+		require.NoError(t, parseParameters(&cfg, ws, func(key string) string { return env[key] }))
+		assert.True(t, cfg.FailOnThresholdBreach)
+		assert.False(t, cfg.ProjektorCompatMode)
+		assert.Empty(t, cfg.OutputPath)
+	})
+	t.Run("Explicit Booleans", func(t *testing.T) {
+		env := map[string]string{
+			"PARAMETER_SCRIPT_PATH":              "./test/script.js",
+			"PARAMETER_FAIL_ON_THRESHOLD_BREACH": "no",
+			"PARAMETER_LOG_PROGRESS":             "yes",
+		}
+
+		cfg := config{}
+		require.NoError(t, parseParameters(&cfg, ws, func(key string) string { return env[key] }))
+		assert.False(t, cfg.FailOnThresholdBreach)
+		assert.True(t, cfg.LogProgress)
+	})
+	t.Run("Reports All Errors", func(t *testing.T) {
+		env := map[string]string{
+			"PARAMETER_FAIL_ON_THRESHOLD_BREACH": "nope",
+			"PARAMETER_COMPATIBILITY_MODE":       "es6",
+			"PARAMETER_NOTIFY":                   "{",
+		}
+
+		cfg := config{}
+		err := parseParameters(&cfg, ws, func(key string) string { return env[key] })
+		assert.ErrorContains(t, err, "'script_path' is required")
+		assert.ErrorContains(t, err, "'fail_on_threshold_breach': value is not a boolean")
+		assert.ErrorContains(t, err, "'compatibility_mode': value must be one of")
+		assert.NotContains(t, err.Error(), "nope")
+		assert.NotContains(t, err.Error(), "es6")
+		assert.ErrorContains(t, err, "'notify': value is not valid JSON")
+	})
+}
+
+func TestParameterSchema(t *testing.T) {
+	names := map[string]bool{}
+
+	for _, param := range parameters {
+		assert.False(t, names[param.Name], "duplicate parameter %s", param.Name)
+		names[param.Name] = true
+
+		assert.NotNil(t, param.Set, param.Name)
+		assert.NotEmpty(t, param.Description, param.Name)
+		assert.NotContains(t, param.Description, "|", param.Name)
+
+		if param.Type == typePath {
+			assert.True(t, len(param.Extensions) > 0 || param.Validate != nil, "%s accepts any file without validation", param.Name)
+		}
+	}
+}
+
+func TestParameterTable(t *testing.T) {
+	table := parameterTable()
+	assert.True(t, strings.HasPrefix(table, "| Name "))
+	assert.Len(t, strings.Split(strings.TrimSpace(table), "\n"), len(parameters)+2)
+
+	docs, err := os.ReadFile(docsPath)
+	require.NoError(t, err)
+
+	start := strings.Index(string(docs), docsTableStart)
+	end := strings.Index(string(docs), docsTableFinish)
+	require.True(t, start >= 0 && end > start, "DOCS.md is missing the parameter table markers")
+
+	generated := string(docs[:start+len(docsTableStart)]) + table + string(docs[end:])
+
+	if *updateDocs {
+		require.NoError(t, os.WriteFile(docsPath, []byte(generated), 0600))
+		return
+	}
+
+	assert.Equal(t, generated, string(docs), "DOCS.md parameter table is out of date, run `make docs`")
+}
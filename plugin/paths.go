@@ -0,0 +1,222 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package plugin
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// File extensions accepted by the path parameters. Extensions are
+// compared case-insensitively.
+var (
+	scriptExtensions  = []string{".js", ".mjs", ".cjs", ".ts", ".tar"}
+	archiveExtensions = []string{".tar"}
+	jsonExtensions    = []string{".json"}
+	shellExtensions   = []string{".sh"}
+	planExtensions    = []string{".yaml", ".yml", ".json"}
+	logExtensions     = []string{".log", ".txt"}
+)
+
+// workspace confines the paths provided in plugin parameters to the
+// directory the repository is cloned into.
+type workspace struct {
+	// root is the absolute path of the workspace, as configured.
+	root string
+	// real is root with its symlinks resolved, which the real paths of
+	// parameters are compared with.
+	real string
+}
+
+// newWorkspace returns the workspace rooted at VELA_WORKSPACE, or at the
+// current working directory if it is not set. The root is kept both as
+// configured and with its symlinks resolved, so paths are compared with
+// the root of the same form.
+func newWorkspace() (workspace, error) {
+	root := os.Getenv("VELA_WORKSPACE")
+	if root == "" {
+		wd, err := os.Getwd()
+		if err != nil {
+			return workspace{}, fmt.Errorf("get working directory: %w", err)
+		}
+
+		root = wd
+	}
+
+	root, err := filepath.Abs(root)
+	if err != nil {
+		return workspace{}, fmt.Errorf("resolve workspace %s: %w", root, err)
+	}
+
+	resolved, err := filepath.EvalSymlinks(root)
+	if err != nil {
+		return workspace{}, fmt.Errorf("resolve workspace %s: %w", root, err)
+	}
+
+	return workspace{root: root, real: resolved}, nil
+}
+
+// resolvePath validates the path provided in a parameter and returns it
+// as a clean, absolute path. Its errors don't echo the input. Relative paths are resolved from the working
+// directory, like k6 and the shell would. An error is
+// returned if extensions are given and the path has an extension not in
+// them, if it refers to a directory, or if it resolves outside the
+// workspace, including through symlinks. An empty input returns an empty
+// path.
+func (w workspace) resolvePath(input string, extensions []string) (string, error) {
+	if input == "" {
+		return "", nil
+	}
+
+	if strings.ContainsFunc(input, unicode.IsControl) {
+		return "", errors.New("path contains control characters")
+	}
+
+	ext := strings.ToLower(filepath.Ext(input))
+	if len(extensions) > 0 && !slices.Contains(extensions, ext) {
+		return "", fmt.Errorf("path must have one of the extensions %s", strings.Join(extensions, ", "))
+	}
+
+	path, real, err := w.confine(input)
+	if err != nil {
+		return "", err
+	}
+
+	if info, err := os.Stat(real); err == nil && info.IsDir() {
+		return "", errors.New("path is a directory")
+	}
+
+	return path, nil
+}
+
+// resolveDir validates the directory provided in a parameter and returns
+// it as a clean, absolute path, confined to the workspace like the paths
+// of resolvePath. An error is returned if it refers to a file. The
+// directory doesn't need to exist yet. An empty input returns an empty
+// path.
+func (w workspace) resolveDir(input string) (string, error) {
+	if input == "" {
+		return "", nil
+	}
+
+	if strings.ContainsFunc(input, unicode.IsControl) {
+		return "", errors.New("path contains control characters")
+	}
+
+	path, real, err := w.confine(input)
+	if err != nil {
+		return "", err
+	}
+
+	if info, err := os.Stat(real); err == nil && !info.IsDir() {
+		return "", errors.New("path is not a directory")
+	}
+
+	return path, nil
+}
+
+// confine returns the absolute path of input and its real path with
+// symlinks resolved, or an error if either is outside the workspace. The
+// absolute path is built from the working directory, which may be the
+// configured or the real root, so it may be inside either.
+func (w workspace) confine(input string) (path, real string, err error) {
+	path, err = filepath.Abs(input)
+	if err != nil {
+		return "", "", fmt.Errorf("resolve path: %w", err)
+	}
+
+	if !isWithin(w.root, path) && !isWithin(w.real, path) {
+		return "", "", fmt.Errorf("path resolves outside the workspace %s", w.root)
+	}
+
+	real, err = evalExistingSymlinks(path)
+	if err != nil {
+		return "", "", fmt.Errorf("resolve symlinks: %w", withoutPath(err))
+	}
+
+	if !isWithin(w.real, real) {
+		return "", "", fmt.Errorf("path links outside the workspace %s", w.root)
+	}
+
+	return path, real, nil
+}
+
+// isWithin returns true if the absolute path is dir or inside of it.
+func isWithin(dir, path string) bool {
+	rel, err := filepath.Rel(dir, path)
+	if err != nil {
+		return false
+	}
+
+	return rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// evalExistingSymlinks resolves the symlinks in the longest existing
+// prefix of path, so paths of files that don't exist yet, such as
+// outputs, can still be checked for symlink escapes.
+func evalExistingSymlinks(path string) (string, error) {
+	existing := path
+
+	var rest []string
+
+	for {
+		resolved, err := filepath.EvalSymlinks(existing)
+		if err == nil {
+			return filepath.Join(append([]string{resolved}, rest...)...), nil
+		}
+
+		if !errors.Is(err, fs.ErrNotExist) {
+			return "", err
+		}
+
+		if info, err := os.Lstat(existing); err == nil && info.Mode()&fs.ModeSymlink != 0 {
+			return "", errors.New("dangling symlink")
+		}
+
+		parent := filepath.Dir(existing)
+		if parent == existing {
+			return path, nil
+		}
+
+		rest = append([]string{filepath.Base(existing)}, rest...)
+		existing = parent
+	}
+}
+
+// validateExecutable checks that a path parameter refers to an existing
+// regular file with execute permissions.
+func validateExecutable(v any) error {
+	path := v.(string)
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("stat path: %w", withoutPath(err))
+	}
+
+	if !info.Mode().IsRegular() {
+		return errors.New("path is not a regular file")
+	}
+
+	if info.Mode().Perm()&0o111 == 0 {
+		return errors.New("path is not executable")
+	}
+
+	return nil
+}
+
+// withoutPath returns err without the path a *fs.PathError adds to its
+// message, so errors about path parameters don't echo their value.
+func withoutPath(err error) error {
+	var pathErr *fs.PathError
+	if errors.As(err, &pathErr) {
+		return pathErr.Err
+	}
+
+	return err
+}
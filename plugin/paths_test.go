@@ -0,0 +1,195 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package plugin
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewWorkspace(t *testing.T) {
+	t.Run("From Env", func(t *testing.T) {
+		dir := t.TempDir()
+		t.Setenv("VELA_WORKSPACE", dir)
+
+		ws, err := newWorkspace()
+		require.NoError(t, err)
+
+		expected, err := filepath.EvalSymlinks(dir)
+		require.NoError(t, err)
+		assert.Equal(t, dir, ws.root)
+		assert.Equal(t, expected, ws.real)
+	})
+	t.Run("Working Directory", func(t *testing.T) {
+		t.Setenv("VELA_WORKSPACE", "")
+
+		ws, err := newWorkspace()
+		require.NoError(t, err)
+
+		wd, err := os.Getwd()
+		require.NoError(t, err)
+
+		expected, err := filepath.EvalSymlinks(wd)
+		require.NoError(t, err)
+		assert.Equal(t, wd, ws.root)
+		assert.Equal(t, expected, ws.real)
+	})
+	t.Run("Missing", func(t *testing.T) {
+		t.Setenv("VELA_WORKSPACE", filepath.Join(t.TempDir(), "missing"))
+
+		_, err := newWorkspace()
+		assert.ErrorContains(t, err, "resolve workspace")
+	})
+}
+
+func TestResolvePath(t *testing.T) {
+	root, err := filepath.EvalSymlinks(t.TempDir())
+	require.NoError(t, err)
+
+	outside, err := filepath.EvalSymlinks(t.TempDir())
+	require.NoError(t, err)
+
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "tests", "v1.2"), 0750))
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "dir.js"), 0750))
+	require.NoError(t, os.Symlink(outside, filepath.Join(root, "escape")))
+	require.NoError(t, os.Symlink(filepath.Join(outside, "missing.js"), filepath.Join(root, "dangling.js")))
+
+	t.Chdir(root)
+
+	ws := workspace{root: root, real: root}
+
+	t.Run("Valid Paths", func(t *testing.T) {
+		for input, expected := range map[string]string{
+			"script.js":                          filepath.Join(root, "script.js"),
+			"./tests/v1.2/load test.JS":          filepath.Join(root, "tests", "v1.2", "load test.JS"),
+			"tests/../script.ts":                 filepath.Join(root, "script.ts"),
+			filepath.Join(root, "bundle.tar"):    filepath.Join(root, "bundle.tar"),
+			"./not/created/yet/module.mjs":       filepath.Join(root, "not", "created", "yet", "module.mjs"),
+			".hidden.cjs":                        filepath.Join(root, ".hidden.cjs"),
+			"tests/v1.2/file-dash_underscore.js": filepath.Join(root, "tests", "v1.2", "file-dash_underscore.js"),
+		} {
+			path, err := ws.resolvePath(input, scriptExtensions)
+			assert.NoError(t, err, input)
+			assert.Equal(t, expected, path, input)
+		}
+	})
+	t.Run("Any Extension", func(t *testing.T) {
+		for input, expected := range map[string]string{
+			"./bin/k6":       filepath.Join(root, "bin", "k6"),
+			"bin/k6-v1.0":    filepath.Join(root, "bin", "k6-v1.0"),
+			"./bin/k6.linux": filepath.Join(root, "bin", "k6.linux"),
+		} {
+			path, err := ws.resolvePath(input, nil)
+			assert.NoError(t, err, input)
+			assert.Equal(t, expected, path, input)
+		}
+
+		_, err := ws.resolvePath("../k6", nil)
+		assert.ErrorContains(t, err, "resolves outside the workspace")
+	})
+	t.Run("Directories", func(t *testing.T) {
+		path, err := ws.resolveDir("./tests/v1.2")
+		assert.NoError(t, err)
+		assert.Equal(t, filepath.Join(root, "tests", "v1.2"), path)
+
+		path, err = ws.resolveDir("artifacts/screenshots")
+		assert.NoError(t, err)
+		assert.Equal(t, filepath.Join(root, "artifacts", "screenshots"), path)
+
+		require.NoError(t, os.WriteFile(filepath.Join(root, "file.txt"), nil, 0600))
+
+		for input, message := range map[string]string{
+			"file.txt":          "is not a directory",
+			"../screenshots":    "resolves outside the workspace",
+			"escape/screenshot": "links outside the workspace",
+		} {
+			_, err := ws.resolveDir(input)
+			assert.ErrorContains(t, err, message, input)
+		}
+	})
+	t.Run("Empty Path", func(t *testing.T) {
+		path, err := ws.resolvePath("", jsonExtensions)
+		assert.NoError(t, err)
+		assert.Empty(t, path)
+	})
+	t.Run("Invalid Paths", func(t *testing.T) {
+		for input, message := range map[string]string{
+			"script.png":                        "must have one of the extensions",
+			"script":                            "must have one of the extensions",
+			"file.js; rm -rf /":                 "must have one of the extensions",
+			"file\n.js":                         "contains control characters",
+			"../script.js":                      "resolves outside the workspace",
+			"tests/../../script.js":             "resolves outside the workspace",
+			filepath.Join(outside, "script.js"): "resolves outside the workspace",
+			"escape/script.js":                  "links outside the workspace",
+			"dangling.js":                       "dangling symlink",
+			"dir.js":                            "is a directory",
+		} {
+			_, err := ws.resolvePath(input, scriptExtensions)
+			assert.ErrorContains(t, err, message, input)
+			assert.NotContains(t, err.Error(), input, input)
+		}
+	})
+}
+
+func TestResolvePathSymlinkedRoot(t *testing.T) {
+	real, err := filepath.EvalSymlinks(t.TempDir())
+	require.NoError(t, err)
+
+	outside, err := filepath.EvalSymlinks(t.TempDir())
+	require.NoError(t, err)
+
+	require.NoError(t, os.MkdirAll(filepath.Join(real, "tests"), 0750))
+
+	root := filepath.Join(t.TempDir(), "workspace")
+	require.NoError(t, os.Symlink(real, root))
+
+	t.Setenv("VELA_WORKSPACE", root)
+
+	ws, err := newWorkspace()
+	require.NoError(t, err)
+
+	for _, wd := range []string{root, real} {
+		t.Chdir(wd)
+
+		path, err := ws.resolvePath("./tests/script.js", scriptExtensions)
+		assert.NoError(t, err, wd)
+		assert.Equal(t, filepath.Join(wd, "tests", "script.js"), path, wd)
+
+		path, err = ws.resolveDir("screenshots")
+		assert.NoError(t, err, wd)
+		assert.Equal(t, filepath.Join(wd, "screenshots"), path, wd)
+
+		_, err = ws.resolvePath("../script.js", scriptExtensions)
+		assert.ErrorContains(t, err, "resolves outside the workspace", wd)
+
+		_, err = ws.resolvePath(filepath.Join(outside, "script.js"), scriptExtensions)
+		assert.ErrorContains(t, err, "resolves outside the workspace", wd)
+	}
+}
+
+func TestValidateExecutable(t *testing.T) {
+	dir := t.TempDir()
+
+	executable := filepath.Join(dir, "k6")
+	require.NoError(t, os.WriteFile(executable, []byte("#!/bin/sh\n"), 0700))
+
+	plain := filepath.Join(dir, "k6-plain")
+	require.NoError(t, os.WriteFile(plain, []byte("#!/bin/sh\n"), 0600))
+
+	assert.NoError(t, validateExecutable(executable))
+
+	for path, message := range map[string]string{
+		plain:                         "is not executable",
+		dir:                           "is not a regular file",
+		filepath.Join(dir, "missing"): "no such file or directory",
+	} {
+		err := validateExecutable(path)
+		assert.ErrorContains(t, err, message, path)
+		assert.NotContains(t, err.Error(), dir, path)
+	}
+}
@@ -0,0 +1,325 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package plugin
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"strings"
+
+	"github.com/go-vela/vela-k6/models"
+	"gopkg.in/yaml.v3"
+)
+
+// planScriptsKey is the key of the per-script settings in the plan file.
+const planScriptsKey = "scripts"
+
+// planScriptKeys are the parameters that can be set per script in the
+// plan file, in addition to the script "path".
+var planScriptKeys = []string{"env", "tags", "outputs", "thresholds", "gates", "profile"}
+
+// JSON Schemas of the json parameters, used to generate the plan schema.
+var (
+	stringMapSchema = map[string]any{
+		"type":                 "object",
+		"additionalProperties": map[string]any{"type": []any{"string", "number", "boolean"}},
+	}
+	thresholdsSchema = map[string]any{
+		"type": "object",
+		"additionalProperties": map[string]any{
+			"type": "array",
+			"items": map[string]any{
+				"anyOf": []any{
+					map[string]any{"type": "string"},
+					map[string]any{
+						"type":     "object",
+						"required": []any{"threshold"},
+						"properties": map[string]any{
+							"threshold":      map[string]any{"type": "string"},
+							"abortOnFail":    map[string]any{"type": "boolean"},
+							"delayAbortEval": map[string]any{"type": "string"},
+						},
+						"additionalProperties": false,
+					},
+				},
+			},
+		},
+	}
+	gatesSchema = map[string]any{
+		"type": "array",
+		"items": map[string]any{
+			"type":     "object",
+			"required": []any{"metric", "stat"},
+			"properties": map[string]any{
+				"metric": map[string]any{"type": "string"},
+				"stat":   map[string]any{"type": "string"},
+				"max":    map[string]any{"type": "number"},
+				"min":    map[string]any{"type": "number"},
+			},
+			"additionalProperties": false,
+		},
+	}
+	notifySchema = map[string]any{
+		"type":     "object",
+		"required": []any{"webhooks"},
+		"properties": map[string]any{
+			"webhooks": map[string]any{
+				"type": "array",
+				"items": map[string]any{
+					"type": "object",
+					"properties": map[string]any{
+						"url":      map[string]any{"type": "string"},
+						"url_env":  map[string]any{"type": "string"},
+						"format":   map[string]any{"enum": []any{formatSlack, formatTeams, formatJSON}},
+						"template": map[string]any{"type": "string"},
+					},
+					"additionalProperties": false,
+				},
+			},
+			"notify_on":            map[string]any{"enum": []any{notifyAlways, notifyFailure, notifyBreach, notifyRegression}},
+			"template":             map[string]any{"type": "string"},
+			"baseline_path":        map[string]any{"type": "string"},
+			"regression_tolerance": map[string]any{"type": "number", "minimum": 0.0},
+		},
+		"additionalProperties": false,
+	}
+)
+
+// readPlan reads the plan file at path, which may be YAML or JSON, and
+// validates it against models.PlanSchema.
+func readPlan(path string) (map[string]any, error) {
+	data, err := os.ReadFile(path) //nolint:gosec // path is validated and confined to the workspace
+	if err != nil {
+		return nil, fmt.Errorf("read plan: %w", err)
+	}
+
+	var decoded any
+	if err := yaml.Unmarshal(data, &decoded); err != nil {
+		return nil, fmt.Errorf("decode plan %s: %w", path, err)
+	}
+
+	// round trip through JSON so values have the types encoding/json uses
+	normalized, err := json.Marshal(decoded)
+	if err != nil {
+		return nil, fmt.Errorf("decode plan %s: %w", path, err)
+	}
+
+	var plan any
+	if err := json.Unmarshal(normalized, &plan); err != nil {
+		return nil, fmt.Errorf("decode plan %s: %w", path, err)
+	}
+
+	if err := models.ValidatePlan(plan); err != nil {
+		return nil, fmt.Errorf("plan %s does not match the schema: %w", path, err)
+	}
+
+	values, _ := plan.(map[string]any)
+
+	return values, nil
+}
+
+// planValues returns the parameter values of the plan for the selected
+// script. The script is selected by 'script_path', as set in lookup or in
+// the plan, or is the only script of the plan. The settings of the
+// selected script override the top-level values, except objects such as
+// env, tags and thresholds, which are merged by key.
+func planValues(plan map[string]any, ws workspace, lookup func(string) string) (map[string]any, error) {
+	values := map[string]any{}
+
+	for key, value := range plan {
+		if key != planScriptsKey {
+			values[key] = value
+		}
+	}
+
+	scripts, _ := plan[planScriptsKey].([]any)
+
+	selected := strings.TrimSpace(lookup(parameterByName("script_path").envName()))
+	if selected == "" {
+		selected, _ = values["script_path"].(string)
+	}
+
+	if selected == "" {
+		switch len(scripts) {
+		case 0:
+			return values, nil
+		case 1:
+			selected, _ = scripts[0].(map[string]any)["path"].(string)
+		default:
+			return nil, fmt.Errorf("'script_path' is required to select one of the %d scripts of the plan", len(scripts))
+		}
+	}
+
+	values["script_path"] = selected
+
+	target, err := ws.resolvePath(selected, scriptExtensions)
+	if err != nil {
+		return values, nil //nolint:nilerr // an invalid 'script_path' is reported when it is parsed
+	}
+
+	for _, entry := range scripts {
+		script, _ := entry.(map[string]any)
+
+		path, err := ws.resolvePath(script["path"].(string), scriptExtensions)
+		if err != nil {
+			return nil, fmt.Errorf("'%s': 'path': %w", planScriptsKey, err)
+		}
+
+		if path != target {
+			continue
+		}
+
+		for _, key := range planScriptKeys {
+			value, ok := script[key]
+			if !ok {
+				continue
+			}
+
+			base, isMap := values[key].(map[string]any)
+			override, _ := value.(map[string]any)
+
+			if isMap && override != nil {
+				merged := maps.Clone(base)
+				maps.Copy(merged, override)
+				value = merged
+			}
+
+			values[key] = value
+		}
+	}
+
+	return values, nil
+}
+
+// planLookup returns a lookup reading parameters from env, falling back
+// to the plan values. Map parameters set in both are merged, with the
+// keys from env taking precedence.
+func planLookup(values map[string]any, env func(string) string) func(string) string {
+	return func(key string) string {
+		raw := env(key)
+		param := parameterByName(strings.ToLower(strings.TrimPrefix(key, "PARAMETER_")))
+
+		value, ok := values[param.Name]
+		if !ok {
+			return raw
+		}
+
+		if strings.TrimSpace(raw) == "" {
+			return encodePlanValue(value)
+		}
+
+		if param.Type != typeMap {
+			return raw
+		}
+
+		base, errBase := parseMap(encodePlanValue(value))
+		override, errOverride := parseMap(strings.TrimSpace(raw))
+
+		if err := errors.Join(errBase, errOverride); err != nil {
+			return raw // reported when the parameter is parsed
+		}
+
+		maps.Copy(base, override)
+
+		return encodePlanValue(base)
+	}
+}
+
+// encodePlanValue returns the raw parameter value of a plan value, in the
+// form Vela passes parameters: strings as is, everything else as JSON.
+func encodePlanValue(value any) string {
+	if s, ok := value.(string); ok {
+		return s
+	}
+
+	b, _ := json.Marshal(value)
+
+	return string(b)
+}
+
+// parameterByName returns the parameter with name from the schema, or a
+// zero parameter if there is none.
+func parameterByName(name string) parameter {
+	for _, param := range parameters {
+		if param.Name == name {
+			return param
+		}
+	}
+
+	return parameter{}
+}
+
+// planSchema returns the JSON Schema of the plan file, generated from the
+// parameter schema and published as models.PlanSchema.
+func planSchema() map[string]any {
+	properties := map[string]any{}
+
+	for _, param := range parameters {
+		if param.Name == "config_path" {
+			continue
+		}
+
+		schema := maps.Clone(param.jsonSchema())
+		schema["description"] = param.Description
+		properties[param.Name] = schema
+	}
+
+	scriptProperties := map[string]any{
+		"path": map[string]any{"type": "string", "description": "path to the k6 script the settings apply to."},
+	}
+
+	for _, key := range planScriptKeys {
+		scriptProperties[key] = properties[key]
+	}
+
+	properties[planScriptsKey] = map[string]any{
+		"type":        "array",
+		"description": "settings applied when the step runs one of these scripts. settings override the top-level values, and objects such as `env`, `tags` and `thresholds` are merged by key.",
+		"items": map[string]any{
+			"type":                 "object",
+			"required":             []any{"path"},
+			"properties":           scriptProperties,
+			"additionalProperties": false,
+		},
+	}
+
+	return map[string]any{
+		"$schema":              "https://json-schema.org/draft/2020-12/schema",
+		"$id":                  "https://github.com/go-vela/vela-k6/models/plan.schema.json",
+		"title":                "vela-k6 test plan",
+		"type":                 "object",
+		"properties":           properties,
+		"additionalProperties": false,
+	}
+}
+
+// jsonSchema returns the JSON Schema of the parameter value in the plan
+// file.
+func (param parameter) jsonSchema() map[string]any {
+	switch param.Type {
+	case typeBool:
+		return map[string]any{"type": "boolean"}
+	case typeInt:
+		return map[string]any{"type": "integer"}
+	case typeList:
+		return map[string]any{"type": "array", "items": map[string]any{"type": "string"}}
+	case typeMap:
+		return stringMapSchema
+	case typeJSON:
+		return param.Schema
+	default:
+		if len(param.Enum) > 0 {
+			enum := make([]any, len(param.Enum))
+			for i, value := range param.Enum {
+				enum[i] = value
+			}
+
+			return map[string]any{"type": "string", "enum": enum}
+		}
+
+		return map[string]any{"type": "string"}
+	}
+}
@@ -0,0 +1,208 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package plugin
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-vela/vela-k6/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const planSchemaPath = "../models/plan.schema.json"
+
+const testPlan = `
+output_path: ./results/output.json
+fail_on_threshold_breach: false
+env:
+  BASE_URL: https://staging.example.com
+  VUS: 10
+tags:
+  team: perf
+outputs: [csv=results.csv]
+thresholds:
+  http_req_failed: [rate<0.01]
+gates:
+  - metric: http_req_duration
+    stat: p(95)
+    max: 500
+scripts:
+  - path: ./k6/load.js
+    env:
+      VUS: 50
+    thresholds:
+      http_req_duration:
+        - threshold: p(95)<800
+          abortOnFail: true
+  - path: ./k6/smoke.js
+    outputs: []
+`
+
+// writePlanWorkspace creates a workspace containing the plan and makes it
+// the working directory.
+func writePlanWorkspace(t *testing.T, plan string) {
+	t.Helper()
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "plan.yml"), []byte(plan), 0600))
+
+	t.Chdir(dir)
+	t.Setenv("VELA_WORKSPACE", dir)
+}
+
+func TestPlanSchema(t *testing.T) {
+	generated, err := json.MarshalIndent(planSchema(), "", "  ")
+	require.NoError(t, err)
+
+	generated = append(generated, '\n')
+
+	if *updateDocs {
+		require.NoError(t, os.WriteFile(planSchemaPath, generated, 0600))
+		return
+	}
+
+	published, err := os.ReadFile(planSchemaPath)
+	require.NoError(t, err)
+	assert.Equal(t, string(generated), string(published), "models/plan.schema.json is out of date, run `make docs`")
+	assert.Equal(t, string(published), string(models.PlanSchema))
+}
+
+func TestReadPlan(t *testing.T) {
+	t.Run("YAML", func(t *testing.T) {
+		writePlanWorkspace(t, testPlan)
+
+		plan, err := readPlan("plan.yml")
+		require.NoError(t, err)
+		assert.Equal(t, "./results/output.json", plan["output_path"])
+		assert.Len(t, plan["scripts"], 2)
+	})
+	t.Run("JSON", func(t *testing.T) {
+		writePlanWorkspace(t, `{"script_path": "./k6/load.js", "log_progress": true}`)
+
+		plan, err := readPlan("plan.yml")
+		require.NoError(t, err)
+		assert.Equal(t, true, plan["log_progress"])
+	})
+	t.Run("Schema Violations", func(t *testing.T) {
+		writePlanWorkspace(t, `
+log_progress: "maybe"
+compatibility_mode: es6
+unknown: true
+gates:
+  - metric: http_reqs
+scripts:
+  - env: {}
+`)
+
+		_, err := readPlan("plan.yml")
+		assert.ErrorContains(t, err, "does not match the schema")
+		assert.ErrorContains(t, err, "/log_progress: expected boolean, got string")
+		assert.ErrorContains(t, err, "/compatibility_mode: es6 must be one of")
+		assert.ErrorContains(t, err, "/unknown: unknown property")
+		assert.ErrorContains(t, err, `/gates/0: missing required property "stat"`)
+		assert.ErrorContains(t, err, `/scripts/0: missing required property "path"`)
+	})
+	t.Run("Invalid YAML", func(t *testing.T) {
+		writePlanWorkspace(t, "scripts: [")
+
+		_, err := readPlan("plan.yml")
+		assert.ErrorContains(t, err, "decode plan")
+	})
+}
+
+func TestPlanValues(t *testing.T) {
+	writePlanWorkspace(t, testPlan)
+
+	ws, err := newWorkspace()
+	require.NoError(t, err)
+
+	plan, err := readPlan("plan.yml")
+	require.NoError(t, err)
+
+	t.Run("Selected Script", func(t *testing.T) {
+		env := map[string]string{"PARAMETER_SCRIPT_PATH": "k6/load.js"}
+
+		values, err := planValues(plan, ws, func(key string) string { return env[key] })
+		require.NoError(t, err)
+		assert.Equal(t, "k6/load.js", values["script_path"])
+		assert.Equal(t, map[string]any{"BASE_URL": "https://staging.example.com", "VUS": 50.0}, values["env"])
+		assert.Contains(t, values["thresholds"], "http_req_duration")
+		assert.Contains(t, values["thresholds"], "http_req_failed")
+		assert.NotContains(t, values, "scripts")
+	})
+	t.Run("Script Override Replaces Lists", func(t *testing.T) {
+		env := map[string]string{"PARAMETER_SCRIPT_PATH": "./k6/smoke.js"}
+
+		values, err := planValues(plan, ws, func(key string) string { return env[key] })
+		require.NoError(t, err)
+		assert.Equal(t, []any{}, values["outputs"])
+	})
+	t.Run("Script Not In Plan", func(t *testing.T) {
+		env := map[string]string{"PARAMETER_SCRIPT_PATH": "./k6/soak.js"}
+
+		values, err := planValues(plan, ws, func(key string) string { return env[key] })
+		require.NoError(t, err)
+		assert.Equal(t, []any{"csv=results.csv"}, values["outputs"])
+	})
+	t.Run("Ambiguous Script", func(t *testing.T) {
+		_, err := planValues(plan, ws, func(string) string { return "" })
+		assert.ErrorContains(t, err, "'script_path' is required to select one of the 2 scripts")
+	})
+}
+
+func TestPlanLookup(t *testing.T) {
+	values := map[string]any{
+		"output_path":  "./output.json",
+		"log_progress": true,
+		"tags":         map[string]any{"team": "perf", "env": "staging"},
+		"outputs":      []any{"csv=results.csv"},
+	}
+	env := map[string]string{
+		"PARAMETER_OUTPUT_PATH": "./override.json",
+		"PARAMETER_TAGS":        "env=prod",
+	}
+
+	lookup := planLookup(values, func(key string) string { return env[key] })
+	assert.Equal(t, "./override.json", lookup("PARAMETER_OUTPUT_PATH"))
+	assert.Equal(t, "true", lookup("PARAMETER_LOG_PROGRESS"))
+	assert.JSONEq(t, `{"team": "perf", "env": "prod"}`, lookup("PARAMETER_TAGS"))
+	assert.Equal(t, `["csv=results.csv"]`, lookup("PARAMETER_OUTPUTS"))
+	assert.Empty(t, lookup("PARAMETER_SETUP_SCRIPT_PATH"))
+}
+
+func TestConfigFromPlan(t *testing.T) {
+	clearEnvironment(t)
+	writePlanWorkspace(t, testPlan)
+	t.Setenv("PARAMETER_CONFIG_PATH", "plan.yml")
+	t.Setenv("PARAMETER_SCRIPT_PATH", "./k6/load.js")
+	t.Setenv("PARAMETER_ENV", `{"BASE_URL": "https://prod.example.com"}`)
+
+	p := &pluginType{}
+	require.NoError(t, p.ConfigFromEnv())
+	assert.Equal(t, absPath(t, "plan.yml"), p.config.ConfigPath)
+	assert.Equal(t, absPath(t, "k6/load.js"), p.config.ScriptPath)
+	assert.Equal(t, absPath(t, "results/output.json"), p.config.OutputPath)
+	assert.False(t, p.config.FailOnThresholdBreach)
+	assert.Equal(t, map[string]string{"BASE_URL": "https://prod.example.com", "VUS": "50"}, p.config.Env)
+	assert.Equal(t, map[string]string{"team": "perf"}, p.config.Tags)
+	assert.Equal(t, []models.Threshold{{Threshold: "p(95)<800", AbortOnFail: true}}, p.config.Thresholds["http_req_duration"])
+	assert.Len(t, p.config.Gates, 1)
+
+	t.Run("Invalid Plan", func(t *testing.T) {
+		t.Setenv("PARAMETER_CONFIG_PATH", "missing.yml")
+
+		err := p.ConfigFromEnv()
+		assert.ErrorIs(t, err, ErrInvalidConfig)
+		assert.ErrorContains(t, err, "'config_path': read plan")
+	})
+	t.Run("Invalid Extension", func(t *testing.T) {
+		t.Setenv("PARAMETER_CONFIG_PATH", "plan.toml")
+
+		err := p.ConfigFromEnv()
+		assert.ErrorContains(t, err, "'config_path': path must have one of the extensions")
+	})
+}
@@ -6,33 +6,72 @@ package plugin
 import (
 	"bufio"
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"io/fs"
 	"log"
 	"os"
 	"os/exec"
 	"path/filepath"
-	"regexp"
 	"strings"
 	"sync"
+	"time"
+
+	"github.com/Masterminds/semver/v3"
 
 	"github.com/go-vela/vela-k6/models"
 )
 
-const thresholdsBreachedExitCode = 99
+// k6 exit codes handled by the plugin.
+const (
+	thresholdsBreachedExitCode = 99
+	invalidConfigExitCode      = 104
+	scriptExceptionExitCode    = 107
+)
+
+// typeScriptVersion is the first k6 version running TypeScript scripts
+// without the experimental_enhanced compatibility mode.
+const typeScriptVersion = "v0.57.0"
+
+// compatibilityModes are the accepted values of the 'compatibility_mode'
+// parameter, which is passed to k6 as --compatibility-mode.
+var compatibilityModes = []string{"base", "extended", "experimental_enhanced"}
+
+// ErrInvalidConfig is returned when the plugin parameters or the k6
+// script are invalid, as opposed to a failed test execution.
+var ErrInvalidConfig = errors.New("invalid configuration")
+
+// summaryExportFile is the name of the summary export written to the
+// temp directory when the plugin needs a summary but no Projektor
+// compatible output was requested.
+const summaryExportFile = "vela-k6-summary.json"
 
 type pluginType struct {
 	config           config
+	scriptOptions    *models.InspectResult
+	k6Version        *models.K6Version
+	result           runResult
+	tempDir          string                                                // tempDir holds the temporary files of the run, created on first use.
 	buildCommand     func(name string, args ...string) models.ShellCommand // buildCommand can be swapped out for a mock function for unit testing.
 	verifyFileExists func(path string) error                               // verifyFileExists can be swapped out for a mock function for unit testing.
+	lookPath         func(file string) (string, error)                     // lookPath can be swapped out for a mock function for unit testing.
 }
 
 // Plugin is the interface that defines the methods for the Vela K6 plugin.
 type Plugin interface {
 	ConfigFromEnv() error
+	DryRunEnabled() bool
+	Quiet() bool
+	DryRun() error
+	InspectScript() error
 	RunSetupScript() error
 	RunPerfTests() error
+	Notify(runErr error) error
+	K6BinaryFromEnv() error
+	K6Version() (*models.K6Version, error)
+	CheckK6() error
 }
 
 // New returns a new instance of the Vela K6 plugin with default
@@ -42,6 +81,7 @@ func New() Plugin {
 	return &pluginType{
 		buildCommand:     buildExecCommand,
 		verifyFileExists: checkOSStat,
+		lookPath:         exec.LookPath,
 	}
 }
 
@@ -58,75 +98,294 @@ func checkOSStat(path string) error {
 	return err
 }
 
-var (
-	validJSFilePattern    = regexp.MustCompile(`^(\./|(\.\./)+)?[a-zA-Z0-9-_/]*[a-zA-Z0-9]\.js$`)
-	validJSONFilePattern  = regexp.MustCompile(`^(\./|(\.\./)+)?[a-zA-Z0-9-_/]*[a-zA-Z0-9]\.json$`)
-	validShellFilePattern = regexp.MustCompile(`^(\./|(\.\./)+)?[a-zA-Z0-9-_/]*[a-zA-Z0-9]\.sh$`)
-)
-
-// ConfigFromEnv returns a Config populated with the values of the Vela
-// parameters. Script and output paths will be sanitized/validated, and
-// an error is returned if the script path is empty or invalid. If the
-// output path is invalid, OutputPath is set to "".
+// ConfigFromEnv populates the plugin configuration with the values of the
+// Vela parameters, as declared in the parameter schema. Paths are
+// validated, resolved and confined to the workspace. An error wrapping
+// ErrInvalidConfig and describing every rejected parameter is returned if
+// any parameter is invalid.
 func (p *pluginType) ConfigFromEnv() error {
-	p.config.ScriptPath = sanitizeScriptPath(os.Getenv("PARAMETER_SCRIPT_PATH"))
-	p.config.OutputPath = sanitizeOutputPath(os.Getenv("PARAMETER_OUTPUT_PATH"))
-	p.config.SetupScriptPath = sanitizeSetupPath(os.Getenv("PARAMETER_SETUP_SCRIPT_PATH"))
-	p.config.FailOnThresholdBreach = !strings.EqualFold(os.Getenv("PARAMETER_FAIL_ON_THRESHOLD_BREACH"), "false")
-	p.config.ProjektorCompatMode = strings.EqualFold(os.Getenv("PARAMETER_PROJEKTOR_COMPAT_MODE"), "true")
-	p.config.LogProgress = strings.EqualFold(os.Getenv("PARAMETER_LOG_PROGRESS"), "true")
-
-	if p.config.ScriptPath == "" || !strings.HasSuffix(p.config.ScriptPath, ".js") {
+	ws, err := newWorkspace()
+	if err != nil {
+		return err
+	}
+
+	p.config = config{}
+
+	lookup, err := configLookup(ws)
+	if err != nil {
+		return fmt.Errorf("%w: 'config_path': %w", ErrInvalidConfig, err)
+	}
+
+	errs := []error{parseParameters(&p.config, ws, lookup)}
+
+	if errs[0] == nil {
+		if err := p.config.applyProfile(); err != nil {
+			errs = append(errs, fmt.Errorf("'profile': %w", err))
+		}
+
+		if err := p.config.checkExtraArgs(); err != nil {
+			errs = append(errs, fmt.Errorf("'extra_args': %w", err))
+		}
+
+		if err := p.checkNoSummary(); err != nil {
+			errs = append(errs, fmt.Errorf("'no_summary': %w", err))
+		}
+
+		if err := p.checkInstances(); err != nil {
+			errs = append(errs, fmt.Errorf("'instances': %w", err))
+		}
+
+		if err := p.checkBrowser(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if p.config.Notify != nil {
+		baseline, err := ws.resolvePath(p.config.Notify.BaselinePath, jsonExtensions)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("'notify': 'baseline_path': %w", err))
+		}
+
+		p.config.Notify.BaselinePath = baseline
+	}
+
+	if err := errors.Join(errs...); err != nil {
 		p.config = config{} // reset config
-		return fmt.Errorf("invalid script file. provide the filepath to a JavaScript file in plugin parameter 'script_path' (e.g. 'script_path: \"/k6-test/script.js\"'). the filepath must follow the regular expression `%s`", validJSFilePattern)
+		return fmt.Errorf("%w: %w", ErrInvalidConfig, err)
 	}
 
 	return nil
 }
 
-// sanitizeScriptPath returns the input string if it satisfies the pattern
-// for a valid JS filepath, and an empty string otherwise.
-func sanitizeScriptPath(input string) string {
-	return validJSFilePattern.FindString(input)
-}
+// configLookup returns the lookup the parameters are read with: the
+// environment, falling back to the plan file set in 'config_path'.
+func configLookup(ws workspace) (func(string) string, error) {
+	configPath, err := ws.resolvePath(strings.TrimSpace(os.Getenv(parameterByName("config_path").envName())), planExtensions)
+	if err != nil || configPath == "" {
+		return os.Getenv, err
+	}
 
-// sanitizeOutputPath returns the input string if it satisfies the pattern
-// for a valid JSON filepath, and an empty string otherwise.
-func sanitizeOutputPath(input string) string {
-	return validJSONFilePattern.FindString(input)
-}
+	plan, err := readPlan(configPath)
+	if err != nil {
+		return nil, err
+	}
+
+	values, err := planValues(plan, ws, os.Getenv)
+	if err != nil {
+		return nil, err
+	}
 
-// sanitizeSetupPath returns the input string if it satisfies the pattern
-// for a valid .sh filepath, and an empty string otherwise.
-func sanitizeSetupPath(input string) string {
-	return validShellFilePattern.FindString(input)
+	return planLookup(values, os.Getenv), nil
 }
 
 // buildK6Command returns a ShellCommand that will execute K6 tests
 // using the script path, output path, and output type in cfg.
 func (p *pluginType) buildK6Command() (cmd models.ShellCommand, err error) {
-	commandArgs := []string{"run"}
-	if !p.config.LogProgress {
-		commandArgs = append(commandArgs, "-q")
+	if err = p.prepareRun(); err != nil {
+		return
 	}
 
+	cmd = p.buildCommand(p.k6Binary(), p.k6RunArgs()...)
+
+	return
+}
+
+// prepareRun creates the directory of the output file and writes the
+// generated k6 config, if any.
+func (p *pluginType) prepareRun() error {
 	if p.config.OutputPath != "" {
 		outputDir := filepath.Dir(p.config.OutputPath)
-		if err = os.MkdirAll(outputDir, os.FileMode(0755)); err != nil {
-			return
+		if err := os.MkdirAll(outputDir, os.FileMode(0755)); err != nil {
+			return err
 		}
+	}
+
+	if err := p.setBrowserEnv(); err != nil {
+		return err
+	}
+
+	return p.writeK6Config()
+}
+
+// k6RunArgs returns the arguments passed to k6 to run the tests. With
+// 'linger', k6 keeps serving its REST API after the test, until the
+// plugin reads the final metrics and stops it.
+func (p *pluginType) k6RunArgs() []string {
+	var processArgs []string
+	if p.config.Linger {
+		processArgs = []string{"--linger"}
+	}
+
+	return p.runArgs(p.apiAddress(), processArgs, p.outputArgs())
+}
+
+// runArgs returns the arguments passed to a k6 process running the tests,
+// with the REST API served on address. processArgs are the flags of the
+// process, such as the part of the test it runs, and outputArgs set its
+// outputs.
+func (p *pluginType) runArgs(address string, processArgs, outputArgs []string) []string {
+	commandArgs := []string{"run"}
+	if !p.config.LogProgress {
+		commandArgs = append(commandArgs, "-q")
+	}
+
+	commandArgs = append(commandArgs, "--log-format=json", fmt.Sprintf("--address=%s", address))
+	commandArgs = append(commandArgs, processArgs...)
+
+	commandArgs = append(commandArgs, p.compatibilityArgs()...)
+	commandArgs = append(commandArgs, p.loadArgs()...)
+	commandArgs = append(commandArgs, outputArgs...)
+	commandArgs = append(commandArgs, p.summaryArgs()...)
+
+	commandArgs = append(commandArgs, p.configArgs()...)
+
+	for _, key := range sortedKeys(p.config.Tags) {
+		commandArgs = append(commandArgs, "--tag", fmt.Sprintf("%s=%s", key, p.config.Tags[key]))
+	}
+
+	commandArgs = append(commandArgs, p.config.ExtraArgs...)
 
+	return append(commandArgs, p.runScriptPath())
+}
+
+// configArgs returns the k6 flags passing the k6 config file and the
+// environment variables of the script, shared by `k6 inspect` and `k6 run`
+// so the script is inspected with the options it runs with.
+func (p *pluginType) configArgs() []string {
+	var args []string
+
+	if configPath := p.k6ConfigPath(); configPath != "" {
+		args = append(args, "--config", configPath)
+	}
+
+	for _, key := range sortedKeys(p.config.Env) {
+		args = append(args, "-e", fmt.Sprintf("%s=%s", key, p.config.Env[key]))
+	}
+
+	return args
+}
+
+// outputArgs returns the k6 flags writing the output file, the configured
+// outputs, the metrics stream and the summary export.
+func (p *pluginType) outputArgs() []string {
+	var args []string
+
+	if p.config.OutputPath != "" {
 		if p.config.ProjektorCompatMode {
-			commandArgs = append(commandArgs, fmt.Sprintf("--summary-export=%s", p.config.OutputPath))
+			args = append(args, fmt.Sprintf("--summary-export=%s", p.config.OutputPath))
 		} else {
-			commandArgs = append(commandArgs, "--out", fmt.Sprintf("json=%s", p.config.OutputPath))
+			args = append(args, "--out", fmt.Sprintf("json=%s", p.config.OutputPath))
 		}
 	}
 
-	commandArgs = append(commandArgs, p.config.ScriptPath)
-	cmd = p.buildCommand("k6", commandArgs...)
+	for _, output := range p.config.Outputs {
+		args = append(args, "--out", output)
+	}
 
-	return
+	if streamPath := p.metricsStreamPath(); streamPath != "" && streamPath != p.config.OutputPath {
+		args = append(args, "--out", fmt.Sprintf("json=%s", streamPath))
+	}
+
+	if summaryPath := p.summaryExportPath(); summaryPath != "" && summaryPath != p.config.OutputPath {
+		args = append(args, fmt.Sprintf("--summary-export=%s", summaryPath))
+	}
+
+	return args
+}
+
+// k6Binary returns the k6 binary the plugin runs: 'k6_binary_path', or
+// k6 on the PATH.
+func (p *pluginType) k6Binary() string {
+	if p.config.K6BinaryPath != "" {
+		return p.config.K6BinaryPath
+	}
+
+	return "k6"
+}
+
+// compatibilityArgs returns the --compatibility-mode flag for the k6
+// commands loading the script, if a compatibility mode is needed.
+func (p *pluginType) compatibilityArgs() []string {
+	mode := p.compatibilityMode()
+	if mode == "" {
+		return nil
+	}
+
+	return []string{fmt.Sprintf("--compatibility-mode=%s", mode)}
+}
+
+// compatibilityMode returns the compatibility mode k6 loads the script
+// with: 'compatibility_mode', or experimental_enhanced for TypeScript
+// scripts when the probed k6 is older than typeScriptVersion and can't
+// run them natively.
+func (p *pluginType) compatibilityMode() string {
+	if p.config.CompatibilityMode != "" || !isTypeScript(p.config.ScriptPath) || p.k6Version == nil {
+		return p.config.CompatibilityMode
+	}
+
+	version, err := semver.NewVersion(p.k6Version.Version)
+	if err != nil || !version.LessThan(semver.MustParse(typeScriptVersion)) {
+		return ""
+	}
+
+	return "experimental_enhanced"
+}
+
+// isTypeScript returns true if the script at path is a TypeScript file.
+func isTypeScript(path string) bool {
+	return strings.EqualFold(filepath.Ext(path), ".ts")
+}
+
+// summaryExportPath returns the path k6 exports its end-of-test summary
+// to, or an empty string if no summary is needed. The Projektor output
+// is reused when present, otherwise a file in the temp directory is used
+// for features that need the parsed summary, and for the final metrics
+// logged when no output file is written.
+func (p *pluginType) summaryExportPath() string {
+	if p.config.ProjektorCompatMode && p.config.OutputPath != "" {
+		return p.config.OutputPath
+	}
+
+	if p.needsSummary() || (p.config.OutputPath == "" && !p.config.NoSummary) {
+		return p.tempPath(summaryExportFile)
+	}
+
+	return ""
+}
+
+// needsSummary returns true if a feature needs the parsed end-of-test
+// summary, such as Projektor compat mode, notifications, gates and the
+// Web Vitals of browser runs.
+func (p *pluginType) needsSummary() bool {
+	if p.config.ProjektorCompatMode && p.config.OutputPath != "" {
+		return true
+	}
+
+	return p.config.Notify != nil || len(p.config.Gates) > 0 || p.config.Browser
+}
+
+// tempPath returns the path of the temporary file name in the directory
+// of the run, which is created on first use so files left by an earlier
+// run are never read. If it can't be created, any stale file at the path
+// in the temp directory is removed instead.
+func (p *pluginType) tempPath(name string) string {
+	if p.tempDir == "" {
+		dir, err := os.MkdirTemp("", "vela-k6-")
+		if err != nil {
+			log.Printf("WARNING: create temp directory: %s\n", err)
+
+			path := filepath.Join(os.TempDir(), name)
+			if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+				log.Printf("WARNING: remove %s: %s\n", path, err)
+			}
+
+			return path
+		}
+
+		p.tempDir = dir
+	}
+
+	return filepath.Join(p.tempDir, name)
 }
 
 // RunSetupScript runs the setup script located at the cfg.SetupScriptPath
@@ -142,66 +401,110 @@ func (p *pluginType) RunSetupScript() error {
 		return fmt.Errorf("read setup script file at %s: %w", p.config.SetupScriptPath, err)
 	}
 
-	cmd := p.buildCommand(p.config.SetupScriptPath)
+	log.Println("Running setup script...")
 
-	stdout, err := cmd.StdoutPipe()
+	if err := streamCommand(p.buildCommand(p.config.SetupScriptPath), p.newRedactor()); err != nil {
+		return fmt.Errorf("run setup script: %w", err)
+	}
+
+	return nil
+}
+
+// RunPerfTests runs the K6 performance test script located at the
+// p.config.ScriptPath and saves the output to p.config.OutputPath if it is present
+// and a valid filepath.
+func (p *pluginType) RunPerfTests() error {
+	err := p.verifyScript()
 	if err != nil {
-		return fmt.Errorf("get stdout pipe: %w", err)
+		return err
+	}
+
+	defer p.removeThresholdsModule()
+
+	if p.config.ArchiveOutputPath != "" {
+		if err := p.archiveScript(); err != nil {
+			return err
+		}
+	}
+
+	started := time.Now()
+
+	var execError error
+	if p.config.Instances > 1 {
+		execError, err = p.runInstances()
+	} else {
+		execError, err = p.runK6()
 	}
 
-	stderr, err := cmd.StderrPipe()
 	if err != nil {
-		return fmt.Errorf("get stderr pipe: %w", err)
+		return err
 	}
 
-	if err := cmd.Start(); err != nil {
-		return fmt.Errorf("start command: %w", err)
+	if p.config.Browser {
+		p.reportBrowserRun(started)
 	}
 
-	log.Println("Running setup script...")
+	if p.result.Cancelled {
+		return errors.New("run cancelled")
+	}
 
-	wg := sync.WaitGroup{}
-	wg.Add(2)
+	if p.result.AbortedBy != "" {
+		return fmt.Errorf("run aborted: abort condition %q violated", p.result.AbortedBy)
+	}
 
-	go readLinesFromPipe(stdout, &wg)
-	go readLinesFromPipe(stderr, &wg)
+	if execError != nil && !p.result.ThresholdsBreached {
+		switch exitCode(execError) {
+		case invalidConfigExitCode:
+			return fmt.Errorf("%w: script %s failed to compile or initialize: %w", ErrInvalidConfig, p.config.ScriptPath, execError)
+		case scriptExceptionExitCode:
+			return fmt.Errorf("script %s threw an exception: %w", p.config.ScriptPath, execError)
+		}
 
-	wg.Wait()
+		return execError
+	}
 
-	err = cmd.Wait()
-	if err != nil {
-		return fmt.Errorf("run setup script: %w", err)
+	if p.result.ThresholdsBreached && p.config.FailOnThresholdBreach {
+		return fmt.Errorf("thresholds breached")
 	}
 
-	return nil
-}
+	if p.config.OutputPath != "" {
+		path, err := filepath.Abs(p.config.OutputPath)
+		if err != nil {
+			log.Printf("save output to %s: %s\n", p.config.OutputPath, err)
+		} else {
+			log.Printf("Output file saved at %s\n", path)
+		}
+	}
 
-// RunPerfTests runs the K6 performance test script located at the
-// p.config.ScriptPath and saves the output to p.config.OutputPath if it is present
-// and a valid filepath.
-func (p *pluginType) RunPerfTests() error {
-	err := p.verifyFileExists(p.config.ScriptPath)
-	if err != nil {
-		return fmt.Errorf("read script file at %s: %w", p.config.ScriptPath, err)
+	if err := p.checkScriptErrors(); err != nil {
+		return err
 	}
 
+	return p.checkGates()
+}
+
+// runK6 runs the tests in a single k6 process and returns the error it
+// exited with. The outcome of the run is stored in the run result.
+func (p *pluginType) runK6() (execError error, err error) {
 	cmd, err := p.buildK6Command()
 	if err != nil {
-		return fmt.Errorf("create output directory: %w", err)
+		return nil, fmt.Errorf("create output directory: %w", err)
 	}
 
-	stdout, err := cmd.StdoutPipe()
+	console, err := p.newConsoleLog()
 	if err != nil {
-		return fmt.Errorf("get stdout pipe: %w", err)
+		return nil, err
 	}
 
-	stderr, err := cmd.StderrPipe()
+	stopWatching, err := p.watchRun(cmd)
 	if err != nil {
-		return fmt.Errorf("get stderr pipe: %w", err)
+		return nil, errors.Join(err, console.Close())
 	}
 
-	if err := cmd.Start(); err != nil {
-		return fmt.Errorf("start command: %w", err)
+	stdout, stderr, err := startCommand(cmd)
+	if err != nil {
+		stopWatching()
+		return nil, errors.Join(err, console.Close())
 	}
 
 	log.Println("Running tests...")
@@ -209,57 +512,175 @@ func (p *pluginType) RunPerfTests() error {
 	wg := sync.WaitGroup{}
 	wg.Add(2)
 
-	go readLinesFromPipe(stdout, &wg)
-	go readLinesFromPipe(stderr, &wg)
+	go console.readLines(streamStdout, "", stdout, &wg)
+	go console.readLines(streamStderr, "", stderr, &wg)
 
 	wg.Wait()
 
-	execError := cmd.Wait()
-	if execError != nil {
-		var exitError models.ErrorWithExitCode
+	execError = cmd.Wait()
 
-		ok := errors.As(execError, &exitError)
+	stopWatching()
 
-		if ok && exitError.ExitCode() == thresholdsBreachedExitCode {
-			if p.config.FailOnThresholdBreach {
-				return fmt.Errorf("thresholds breached")
-			}
-		} else {
-			return execError
-		}
+	if err := console.Close(); err != nil {
+		log.Printf("WARNING: %s\n", err)
 	}
 
-	if p.config.OutputPath != "" {
-		path, err := filepath.Abs(p.config.OutputPath)
+	p.result.Logs = console.stats
+	p.result.Logs.logSummary()
+
+	if exitCode(execError) == thresholdsBreachedExitCode {
+		p.result.ThresholdsBreached = true
+	}
+
+	if summaryPath := p.summaryExportPath(); summaryPath != "" {
+		summary, err := models.ReadSummary(summaryPath)
 		if err != nil {
-			log.Printf("save output to %s: %s\n", p.config.OutputPath, err)
-		} else {
-			log.Printf("Output file saved at %s\n", path)
+			log.Printf("WARNING: read summary export: %s\n", err)
 		}
+
+		p.result.Summary = summary
 	}
 
-	return nil
+	if p.config.OutputPath == "" {
+		final := p.result.Summary
+		if final == nil {
+			final = p.result.APISummary
+		}
+
+		logFinalMetrics(final)
+	}
+
+	return execError, nil
+}
+
+// exitCode returns the exit code of a failed k6 process, or -1 if err
+// carries none.
+func exitCode(err error) int {
+	var exitError models.ErrorWithExitCode
+	if errors.As(err, &exitError) {
+		return exitError.ExitCode()
+	}
+
+	return -1
+}
+
+// startCommand opens the stdout and stderr pipes of cmd and starts it.
+func startCommand(cmd models.ShellCommand) (stdout, stderr io.ReadCloser, err error) {
+	stdout, err = cmd.StdoutPipe()
+	if err != nil {
+		return nil, nil, fmt.Errorf("get stdout pipe: %w", err)
+	}
+
+	stderr, err = cmd.StderrPipe()
+	if err != nil {
+		return nil, nil, fmt.Errorf("get stderr pipe: %w", err)
+	}
+
+	if err = cmd.Start(); err != nil {
+		return nil, nil, fmt.Errorf("start command: %w", err)
+	}
+
+	return stdout, stderr, nil
 }
 
-// readLinesFromPipe will read each line from pipe and log it. A WaitGroup
-// may optionally be passed in, in which case Done() will be called
-// once the pipe is closed.
-func readLinesFromPipe(pipe io.ReadCloser, wg *sync.WaitGroup) {
+// streamCommand starts cmd, logs its stdout and stderr line by line with
+// the secrets masked by r, and waits for it to exit.
+func streamCommand(cmd models.ShellCommand, r *redactor) error {
+	stdout, stderr, err := startCommand(cmd)
+	if err != nil {
+		return err
+	}
+
+	wg := sync.WaitGroup{}
+	wg.Add(2)
+
+	go readLinesFromPipe(stdout, &wg, r)
+	go readLinesFromPipe(stderr, &wg, r)
+
+	wg.Wait()
+
+	return cmd.Wait()
+}
+
+// readLinesFromPipe will read each line from pipe and log it, with the
+// secrets masked by r. A WaitGroup may optionally be passed in, in which
+// case Done() will be called once the pipe is closed.
+func readLinesFromPipe(pipe io.ReadCloser, wg *sync.WaitGroup, r *redactor) {
 	if wg != nil {
 		defer wg.Done()
 	}
 
 	scanner := bufio.NewScanner(pipe)
 	for scanner.Scan() {
-		log.Println(scanner.Text())
+		log.Println(r.redact(scanner.Text()))
 	}
 }
 
 type config struct {
-	ScriptPath            string
-	OutputPath            string
-	SetupScriptPath       string
-	FailOnThresholdBreach bool
-	ProjektorCompatMode   bool
-	LogProgress           bool
+	ScriptPath            string                        `json:"script_path"`
+	OutputPath            string                        `json:"output_path"`
+	SetupScriptPath       string                        `json:"setup_script_path"`
+	ArchiveOutputPath     string                        `json:"archive_output_path,omitempty"`
+	FailOnThresholdBreach bool                          `json:"fail_on_threshold_breach"`
+	ProjektorCompatMode   bool                          `json:"projektor_compat_mode"`
+	LogProgress           bool                          `json:"log_progress"`
+	RequireThresholds     bool                          `json:"require_thresholds"`
+	DryRun                bool                          `json:"dry_run"`
+	Quiet                 bool                          `json:"quiet"`
+	CompatibilityMode     string                        `json:"compatibility_mode,omitempty"`
+	Notify                *models.NotifyConfig          `json:"notify,omitempty"`
+	ConfigPath            string                        `json:"config_path,omitempty"`
+	Env                   map[string]string             `json:"env,omitempty"`
+	SecretEnv             []string                      `json:"secret_env,omitempty"`
+	RedactPatterns        []string                      `json:"redact_patterns,omitempty"`
+	Tags                  map[string]string             `json:"tags,omitempty"`
+	Outputs               []string                      `json:"outputs,omitempty"`
+	Thresholds            map[string][]models.Threshold `json:"thresholds,omitempty"`
+	ThresholdsMode        string                        `json:"thresholds_mode"`
+	Gates                 []models.Gate                 `json:"gates,omitempty"`
+	Profile               string                        `json:"profile,omitempty"`
+	Profiles              map[string]models.Profile     `json:"profiles,omitempty"`
+	VUs                   int                           `json:"vus,omitempty"`
+	Duration              string                        `json:"duration,omitempty"`
+	Stages                []models.Stage                `json:"stages,omitempty"`
+	K6ConfigPath          string                        `json:"k6_config_path,omitempty"`
+	ExtraArgs             []string                      `json:"extra_args,omitempty"`
+	SummaryTrendStats     []string                      `json:"summary_trend_stats,omitempty"`
+	SummaryTimeUnit       string                        `json:"summary_time_unit,omitempty"`
+	NoSummary             bool                          `json:"no_summary"`
+	ConsoleLogPath        string                        `json:"console_log_path,omitempty"`
+	ConsoleLogMode        string                        `json:"console_log_mode"`
+	LogTailLines          int                           `json:"log_tail_lines,omitempty"`
+	MaxScriptErrors       *int                          `json:"max_script_errors,omitempty"`
+	ProgressInterval      duration                      `json:"progress_interval,omitempty"`
+	AbortConditions       []abortCondition              `json:"abort_conditions,omitempty"`
+	APIAddress            string                        `json:"api_address"`
+	Linger                bool                          `json:"linger"`
+	Instances             int                           `json:"instances"`
+	MinK6Version          string                        `json:"min_k6_version,omitempty"`
+	K6BinaryPath          string                        `json:"k6_binary_path,omitempty"`
+	K6Extensions          []string                      `json:"k6_extensions,omitempty"`
+	Browser               bool                          `json:"browser"`
+	BrowserHeadless       bool                          `json:"browser_headless"`
+	BrowserArgs           []string                      `json:"browser_args,omitempty"`
+	BrowserTimeout        duration                      `json:"browser_timeout,omitempty"`
+	BrowserExecutablePath string                        `json:"browser_executable_path,omitempty"`
+	ScreenshotsPath       string                        `json:"screenshots_path,omitempty"`
+
+	// k6Options and k6Thresholds are decoded from the k6 config file.
+	k6Options    map[string]json.RawMessage
+	k6Thresholds map[string][]models.Threshold
+}
+
+// runResult holds the outcome of the k6 run, which is used after the
+// tests complete.
+type runResult struct {
+	ThresholdsBreached bool
+	Summary            *models.Summary
+	Logs               k6LogStats
+	AbortedBy          string
+	Cancelled          bool
+	// APISummary holds the last metrics read from the k6 REST API, which
+	// are final when k6 lingered after the test with 'linger'.
+	APISummary *models.Summary
 }
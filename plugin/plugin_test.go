@@ -8,10 +8,13 @@ import (
 	"fmt"
 	"io"
 	"log"
+	"os"
+	"path/filepath"
 	"strings"
 	"sync"
 	"testing"
 
+	"github.com/go-vela/vela-k6/models"
 	"github.com/go-vela/vela-k6/plugin/mock"
 	"github.com/stretchr/testify/assert"
 )
@@ -23,127 +26,165 @@ func setFilePathEnvs(t *testing.T) {
 }
 
 func clearEnvironment(t *testing.T) {
-	t.Setenv("PARAMETER_SCRIPT_PATH", "")
-	t.Setenv("PARAMETER_OUTPUT_PATH", "")
-	t.Setenv("PARAMETER_SETUP_SCRIPT_PATH", "")
-	t.Setenv("PARAMETER_PROJEKTOR_COMPAT_MODE", "")
-	t.Setenv("PARAMETER_FAIL_ON_THRESHOLD_BREACH", "")
-	t.Setenv("PARAMETER_LOG_PROGRESS", "")
+	for _, param := range parameters {
+		t.Setenv(param.envName(), "")
+	}
 }
 
-func TestSanitizeScriptPath(t *testing.T) {
-	t.Run("Valid Filepaths", func(t *testing.T) {
-		t.Parallel()
-
-		assert.Equal(t, "file.js", sanitizeScriptPath("file.js"))
-		assert.Equal(t, "./file.js", sanitizeScriptPath("./file.js"))
-		assert.Equal(t, "../file.js", sanitizeScriptPath("../file.js"))
-		assert.Equal(t, "../../../file.js", sanitizeScriptPath("../../../file.js"))
-		assert.Equal(t, "file-dash_underscore.js", sanitizeScriptPath("file-dash_underscore.js"))
-		assert.Equal(t, "path/to/file.js", sanitizeScriptPath("path/to/file.js"))
-		assert.Equal(t, "/path/to/file.js", sanitizeScriptPath("/path/to/file.js"))
-		assert.Equal(t, "file.json", sanitizeOutputPath("file.json"))
-		assert.Equal(t, "./file.json", sanitizeOutputPath("./file.json"))
-		assert.Equal(t, "../file.json", sanitizeOutputPath("../file.json"))
-		assert.Equal(t, "../../../file.json", sanitizeOutputPath("../../../file.json"))
-		assert.Equal(t, "file-dash_underscore.json", sanitizeOutputPath("file-dash_underscore.json"))
-		assert.Equal(t, "path/to/file.json", sanitizeOutputPath("path/to/file.json"))
-		assert.Equal(t, "/path/to/file.json", sanitizeOutputPath("/path/to/file.json"))
-	})
-
-	t.Run("Invalid Filepaths", func(t *testing.T) {
-		t.Parallel()
+func TestConfigFromEnv(t *testing.T) {
+	clearEnvironment(t)
+	t.Setenv("VELA_WORKSPACE", "")
+	t.Run("Files Only", func(t *testing.T) {
+		setFilePathEnvs(t)
 
-		assert.Empty(t, sanitizeScriptPath(".../file.js"))
-		assert.Empty(t, sanitizeScriptPath("./../file.js"))
-		assert.Empty(t, sanitizeScriptPath("*/file.js"))
-		assert.Empty(t, sanitizeScriptPath(".file.js"))
-		assert.Empty(t, sanitizeScriptPath("/.js"))
-		assert.Empty(t, sanitizeScriptPath("-.js"))
-		assert.Empty(t, sanitizeScriptPath("_.js"))
-		assert.Empty(t, sanitizeScriptPath("_invalid$name.js"))
-		assert.Empty(t, sanitizeScriptPath("invalid$name.js"))
-		assert.Empty(t, sanitizeScriptPath("invalidformat.png"))
-		assert.Empty(t, sanitizeScriptPath("file.js; rm -rf /"))
-		assert.Empty(t, sanitizeScriptPath("file.js && suspicious-call"))
-		assert.Empty(t, sanitizeOutputPath(".../file.json"))
-		assert.Empty(t, sanitizeOutputPath("./../file.json"))
-		assert.Empty(t, sanitizeOutputPath("*/file.json"))
-		assert.Empty(t, sanitizeOutputPath(".file.json"))
-		assert.Empty(t, sanitizeOutputPath("/.json"))
-		assert.Empty(t, sanitizeOutputPath("-.json"))
-		assert.Empty(t, sanitizeOutputPath("_.json"))
-		assert.Empty(t, sanitizeOutputPath("_invalid$name.json"))
-		assert.Empty(t, sanitizeOutputPath("invalid$name.json"))
-		assert.Empty(t, sanitizeOutputPath("invalidformat.png"))
-		assert.Empty(t, sanitizeOutputPath("file.json; rm -rf /"))
-		assert.Empty(t, sanitizeOutputPath("file.json && suspicious-call"))
+		p := &pluginType{}
+		err := p.ConfigFromEnv()
+		assert.NoError(t, err)
+		assert.Equal(t, absPath(t, "./test/script.js"), p.config.ScriptPath)
+		assert.Equal(t, absPath(t, "./output.json"), p.config.OutputPath)
 	})
-}
+	t.Run("Non-Default Options", func(t *testing.T) {
+		setFilePathEnvs(t)
+		t.Setenv("PARAMETER_PROJEKTOR_COMPAT_MODE", "true")
+		t.Setenv("PARAMETER_FAIL_ON_THRESHOLD_BREACH", "false")
+		t.Setenv("PARAMETER_REQUIRE_THRESHOLDS", "true")
+		t.Setenv("PARAMETER_DRY_RUN", "true")
 
-func TestSanitizeSetupPath(t *testing.T) {
-	t.Run("Valid Filepaths", func(t *testing.T) {
-		t.Parallel()
+		p := &pluginType{}
+		err := p.ConfigFromEnv()
+		assert.NoError(t, err)
+		assert.Equal(t, absPath(t, "./test/script.js"), p.config.ScriptPath)
+		assert.Equal(t, absPath(t, "./output.json"), p.config.OutputPath)
+		assert.True(t, p.config.ProjektorCompatMode)
+		assert.False(t, p.config.FailOnThresholdBreach)
+		assert.True(t, p.config.RequireThresholds)
+		assert.True(t, p.config.DryRun)
+	})
+	t.Run("Notify", func(t *testing.T) {
+		setFilePathEnvs(t)
+		t.Setenv("PARAMETER_NOTIFY", `{"webhooks": [{"url": "https://example.com/hook"}], "notify_on": "breach"}`)
 
-		assert.Equal(t, "file.sh", sanitizeSetupPath("file.sh"))
-		assert.Equal(t, "./file.sh", sanitizeSetupPath("./file.sh"))
-		assert.Equal(t, "../file.sh", sanitizeSetupPath("../file.sh"))
-		assert.Equal(t, "../../../file.sh", sanitizeSetupPath("../../../file.sh"))
-		assert.Equal(t, "file-dash_underscore.sh", sanitizeSetupPath("file-dash_underscore.sh"))
-		assert.Equal(t, "path/to/file.sh", sanitizeSetupPath("path/to/file.sh"))
-		assert.Equal(t, "/path/to/file.sh", sanitizeSetupPath("/path/to/file.sh"))
+		p := &pluginType{}
+		err := p.ConfigFromEnv()
+		assert.NoError(t, err)
+		assert.Equal(t, "breach", p.config.Notify.NotifyOn)
 	})
+	t.Run("Invalid Notify", func(t *testing.T) {
+		setFilePathEnvs(t)
+		t.Setenv("PARAMETER_NOTIFY", `{"webhooks": []}`)
 
-	t.Run("Invalid Filepaths", func(t *testing.T) {
-		t.Parallel()
+		p := &pluginType{}
+		err := p.ConfigFromEnv()
+		assert.ErrorContains(t, err, "'notify': at least one webhook must be provided")
+		assert.Empty(t, p.config)
+	})
+	t.Run("Archive", func(t *testing.T) {
+		setFilePathEnvs(t)
+		t.Setenv("PARAMETER_SCRIPT_PATH", "./test/bundle.tar")
+		t.Setenv("PARAMETER_ARCHIVE_OUTPUT_PATH", "./artifacts/bundle.tar")
 
-		assert.Empty(t, sanitizeSetupPath(".../file.sh"))
-		assert.Empty(t, sanitizeSetupPath("./../file.sh"))
-		assert.Empty(t, sanitizeSetupPath("*/file.sh"))
-		assert.Empty(t, sanitizeSetupPath(".file.sh"))
-		assert.Empty(t, sanitizeSetupPath("/.sh"))
-		assert.Empty(t, sanitizeSetupPath("-.sh"))
-		assert.Empty(t, sanitizeSetupPath("_.sh"))
-		assert.Empty(t, sanitizeSetupPath("_invalid$name.sh"))
-		assert.Empty(t, sanitizeSetupPath("invalid$name.sh"))
-		assert.Empty(t, sanitizeSetupPath("invalidformat.png"))
-		assert.Empty(t, sanitizeSetupPath("file.sh; rm -rf /"))
-		assert.Empty(t, sanitizeSetupPath("file.sh && suspicious-call"))
+		p := &pluginType{}
+		err := p.ConfigFromEnv()
+		assert.NoError(t, err)
+		assert.Equal(t, absPath(t, "./test/bundle.tar"), p.config.ScriptPath)
+		assert.Equal(t, absPath(t, "./artifacts/bundle.tar"), p.config.ArchiveOutputPath)
 	})
-}
+	t.Run("Invalid Archive Output Path", func(t *testing.T) {
+		setFilePathEnvs(t)
+		t.Setenv("PARAMETER_ARCHIVE_OUTPUT_PATH", "./artifacts/bundle.zip")
 
-func TestConfigFromEnv(t *testing.T) {
-	clearEnvironment(t)
-	t.Run("Files Only", func(t *testing.T) {
+		p := &pluginType{}
+		err := p.ConfigFromEnv()
+		assert.ErrorContains(t, err, "'archive_output_path'")
+		assert.Empty(t, p.config)
+	})
+	t.Run("TypeScript", func(t *testing.T) {
 		setFilePathEnvs(t)
+		t.Setenv("PARAMETER_SCRIPT_PATH", "./test/script.ts")
+		t.Setenv("PARAMETER_COMPATIBILITY_MODE", "Experimental_Enhanced")
 
 		p := &pluginType{}
 		err := p.ConfigFromEnv()
 		assert.NoError(t, err)
-		assert.Equal(t, "./test/script.js", p.config.ScriptPath)
-		assert.Equal(t, "./output.json", p.config.OutputPath)
+		assert.Equal(t, absPath(t, "./test/script.ts"), p.config.ScriptPath)
+		assert.Equal(t, "experimental_enhanced", p.config.CompatibilityMode)
 	})
-	t.Run("Non-Default Options", func(t *testing.T) {
+	t.Run("Invalid Compatibility Mode", func(t *testing.T) {
 		setFilePathEnvs(t)
-		t.Setenv("PARAMETER_PROJEKTOR_COMPAT_MODE", "true")
-		t.Setenv("PARAMETER_FAIL_ON_THRESHOLD_BREACH", "false")
+		t.Setenv("PARAMETER_COMPATIBILITY_MODE", "es6")
+
+		p := &pluginType{}
+		err := p.ConfigFromEnv()
+		assert.ErrorContains(t, err, "'compatibility_mode'")
+		assert.Empty(t, p.config)
+	})
+	t.Run("Profile", func(t *testing.T) {
+		setFilePathEnvs(t)
+		t.Setenv("PARAMETER_PROFILE", "canary")
+		t.Setenv("PARAMETER_PROFILES", `{"canary": {"vus": 2, "duration": "30s"}}`)
 
 		p := &pluginType{}
 		err := p.ConfigFromEnv()
 		assert.NoError(t, err)
-		assert.Equal(t, "./test/script.js", p.config.ScriptPath)
-		assert.Equal(t, "./output.json", p.config.OutputPath)
-		assert.True(t, p.config.ProjektorCompatMode)
-		assert.False(t, p.config.FailOnThresholdBreach)
+		assert.Equal(t, 2, p.config.VUs)
+		assert.Equal(t, "30s", p.config.Duration)
+	})
+	t.Run("Unknown Profile", func(t *testing.T) {
+		setFilePathEnvs(t)
+		t.Setenv("PARAMETER_PROFILE", "nightly")
+
+		p := &pluginType{}
+		err := p.ConfigFromEnv()
+		assert.ErrorIs(t, err, ErrInvalidConfig)
+		assert.ErrorContains(t, err, `'profile': unknown profile "nightly"`)
+		assert.Empty(t, p.config)
 	})
 	t.Run("Invalid Script Path", func(t *testing.T) {
+		t.Setenv("PARAMETER_COMPATIBILITY_MODE", "")
+		t.Setenv("PARAMETER_ARCHIVE_OUTPUT_PATH", "")
+		t.Setenv("PARAMETER_NOTIFY", "")
 		t.Setenv("PARAMETER_SCRIPT_PATH", "./script.png")
 
 		p := &pluginType{}
 		err := p.ConfigFromEnv()
-		assert.Error(t, err)
+		assert.ErrorIs(t, err, ErrInvalidConfig)
+		assert.ErrorContains(t, err, "'script_path': path must have one of the extensions")
 		assert.Empty(t, p.config)
 	})
+	t.Run("Reports All Invalid Paths", func(t *testing.T) {
+		t.Setenv("PARAMETER_SCRIPT_PATH", "")
+		t.Setenv("PARAMETER_OUTPUT_PATH", "../../output.json")
+		t.Setenv("PARAMETER_SETUP_SCRIPT_PATH", "./setup.png")
+
+		p := &pluginType{}
+		err := p.ConfigFromEnv()
+		assert.ErrorIs(t, err, ErrInvalidConfig)
+		assert.ErrorContains(t, err, "'script_path' is required")
+		assert.ErrorContains(t, err, "'output_path': path resolves outside the workspace")
+		assert.ErrorContains(t, err, "'setup_script_path': path must have one of the extensions .sh")
+		assert.Empty(t, p.config)
+	})
+	t.Run("Workspace From Env", func(t *testing.T) {
+		setFilePathEnvs(t)
+		t.Setenv("VELA_WORKSPACE", t.TempDir())
+
+		p := &pluginType{}
+		err := p.ConfigFromEnv()
+		assert.ErrorContains(t, err, "'script_path': path resolves outside the workspace")
+	})
+}
+
+// absPath returns the absolute path of the relative path, resolved from
+// the working directory.
+func absPath(t *testing.T, path string) string {
+	t.Helper()
+
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return abs
 }
 
 func TestBuildK6Command(t *testing.T) {
@@ -152,13 +193,28 @@ func TestBuildK6Command(t *testing.T) {
 
 		p := &pluginType{
 			config:           config{ScriptPath: "./test/script.js"},
+			tempDir:          t.TempDir(),
+			buildCommand:     buildExecCommand,
+			verifyFileExists: checkOSStat,
+		}
+
+		cmd, err := p.buildK6Command()
+		assert.NoError(t, err)
+		assert.Contains(t, cmd.String(), fmt.Sprintf("k6 run -q --log-format=json --address=localhost:6565 --summary-export=%s ./test/script.js", filepath.Join(p.tempDir, summaryExportFile)))
+	})
+	t.Run("Custom Binary", func(t *testing.T) {
+		t.Parallel()
+
+		p := &pluginType{
+			config:           config{ScriptPath: "./test/script.js", K6BinaryPath: "./bin/k6"},
+			tempDir:          t.TempDir(),
 			buildCommand:     buildExecCommand,
 			verifyFileExists: checkOSStat,
 		}
 
 		cmd, err := p.buildK6Command()
 		assert.NoError(t, err)
-		assert.Contains(t, cmd.String(), "k6 run -q ./test/script.js")
+		assert.Contains(t, cmd.String(), fmt.Sprintf("bin/k6 run -q --log-format=json --address=localhost:6565 --summary-export=%s ./test/script.js", filepath.Join(p.tempDir, summaryExportFile)))
 	})
 	t.Run("Projektor Compat Output", func(t *testing.T) {
 		t.Parallel()
@@ -170,13 +226,14 @@ func TestBuildK6Command(t *testing.T) {
 				SetupScriptPath:     "./test/setup.sh",
 				ProjektorCompatMode: true,
 			},
+			tempDir:          t.TempDir(),
 			buildCommand:     buildExecCommand,
 			verifyFileExists: checkOSStat,
 		}
 
 		cmd, err := p.buildK6Command()
 		assert.NoError(t, err)
-		assert.Contains(t, cmd.String(), "k6 run -q --summary-export=./output.json ./test/script.js")
+		assert.Contains(t, cmd.String(), "k6 run -q --log-format=json --address=localhost:6565 --summary-export=./output.json --summary-trend-stats=p(95),p(90),avg,min,max,med ./test/script.js")
 	})
 	t.Run("K6 Recommended Output", func(t *testing.T) {
 		t.Parallel()
@@ -187,13 +244,97 @@ func TestBuildK6Command(t *testing.T) {
 				OutputPath:      "./output.json",
 				SetupScriptPath: "./test/setup.sh",
 			},
+			tempDir:          t.TempDir(),
+			buildCommand:     buildExecCommand,
+			verifyFileExists: checkOSStat,
+		}
+
+		cmd, err := p.buildK6Command()
+		assert.NoError(t, err)
+		assert.Contains(t, cmd.String(), "k6 run -q --log-format=json --address=localhost:6565 --out json=./output.json ./test/script.js")
+	})
+	t.Run("Summary Export For Notifications", func(t *testing.T) {
+		t.Parallel()
+
+		p := &pluginType{
+			config: config{
+				ScriptPath: "./test/script.js",
+				OutputPath: "./output.json",
+				Notify:     &models.NotifyConfig{},
+			},
+			tempDir:          t.TempDir(),
+			buildCommand:     buildExecCommand,
+			verifyFileExists: checkOSStat,
+		}
+
+		cmd, err := p.buildK6Command()
+		assert.NoError(t, err)
+		assert.Contains(t, cmd.String(), fmt.Sprintf("k6 run -q --log-format=json --address=localhost:6565 --out json=./output.json --summary-export=%s ./test/script.js", filepath.Join(p.tempDir, summaryExportFile)))
+	})
+	t.Run("Compatibility Mode", func(t *testing.T) {
+		t.Parallel()
+
+		p := &pluginType{
+			config: config{
+				ScriptPath:        "./test/script.ts",
+				CompatibilityMode: "experimental_enhanced",
+			},
+			tempDir:          t.TempDir(),
 			buildCommand:     buildExecCommand,
 			verifyFileExists: checkOSStat,
 		}
 
 		cmd, err := p.buildK6Command()
 		assert.NoError(t, err)
-		assert.Contains(t, cmd.String(), "k6 run -q --out json=./output.json ./test/script.js")
+		assert.Contains(t, cmd.String(), fmt.Sprintf("k6 run -q --log-format=json --address=localhost:6565 --compatibility-mode=experimental_enhanced --summary-export=%s ./test/script.ts", filepath.Join(p.tempDir, summaryExportFile)))
+	})
+	t.Run("TypeScript On Older k6", func(t *testing.T) {
+		t.Parallel()
+
+		p := &pluginType{
+			config:           config{ScriptPath: "./test/script.ts"},
+			k6Version:        &models.K6Version{Version: "v0.55.0"},
+			tempDir:          t.TempDir(),
+			buildCommand:     buildExecCommand,
+			verifyFileExists: checkOSStat,
+		}
+
+		cmd, err := p.buildK6Command()
+		assert.NoError(t, err)
+		assert.Contains(t, cmd.String(), fmt.Sprintf("k6 run -q --log-format=json --address=localhost:6565 --compatibility-mode=experimental_enhanced --summary-export=%s ./test/script.ts", filepath.Join(p.tempDir, summaryExportFile)))
+
+		p.k6Version = &models.K6Version{Version: "v1.0.0"}
+		assert.NotContains(t, p.k6RunArgs(), "--compatibility-mode=experimental_enhanced")
+	})
+	t.Run("Plan Options", func(t *testing.T) {
+		t.Parallel()
+
+		dir := t.TempDir()
+
+		p := &pluginType{
+			config: config{
+				ScriptPath: filepath.Join(dir, "script.js"),
+				Outputs:    []string{"csv=results.csv"},
+				Env:        map[string]string{"VUS": "10", "BASE_URL": "https://example.com"},
+				Tags:       map[string]string{"team": "perf"},
+				Thresholds: map[string][]models.Threshold{"http_req_failed": {{Threshold: "rate<0.01"}}},
+			},
+			tempDir:          t.TempDir(),
+			buildCommand:     buildExecCommand,
+			verifyFileExists: checkOSStat,
+		}
+
+		cmd, err := p.buildK6Command()
+		assert.NoError(t, err)
+		assert.Contains(t, cmd.String(), fmt.Sprintf("k6 run -q --log-format=json --address=localhost:6565 --out csv=results.csv --summary-export=%s --config %s -e BASE_URL=https://example.com -e VUS=10 --tag team=perf %s", filepath.Join(p.tempDir, summaryExportFile), filepath.Join(p.tempDir, k6ConfigFile), filepath.Join(dir, ".vela-k6-script.js")))
+
+		generated, err := os.ReadFile(filepath.Join(p.tempDir, k6ConfigFile))
+		assert.NoError(t, err)
+		assert.JSONEq(t, `{"thresholds": {"http_req_failed": ["rate<0.01"]}}`, string(generated))
+
+		module, err := os.ReadFile(filepath.Join(dir, ".vela-k6-script.js"))
+		assert.NoError(t, err)
+		assert.Contains(t, string(module), `import * as script from "./script.js";`)
 	})
 	t.Run("Verbose logging", func(t *testing.T) {
 		t.Parallel()
@@ -203,13 +344,14 @@ func TestBuildK6Command(t *testing.T) {
 				ScriptPath:  "./test/script.js",
 				LogProgress: true,
 			},
+			tempDir:          t.TempDir(),
 			buildCommand:     buildExecCommand,
 			verifyFileExists: checkOSStat,
 		}
 
 		cmd, err := p.buildK6Command()
 		assert.NoError(t, err)
-		assert.Contains(t, cmd.String(), "k6 run ./test/script.js")
+		assert.Contains(t, cmd.String(), fmt.Sprintf("k6 run --log-format=json --address=localhost:6565 --summary-export=%s ./test/script.js", filepath.Join(p.tempDir, summaryExportFile)))
 	})
 }
 
@@ -408,6 +550,41 @@ func TestRunPerfTests(t *testing.T) {
 		}
 
 		assert.NoError(t, p.RunPerfTests())
+		assert.True(t, p.result.ThresholdsBreached)
+	})
+
+	t.Run("Compile error", func(t *testing.T) {
+		t.Parallel()
+
+		p := &pluginType{
+			config: config{
+				ScriptPath:            "./test/script.js",
+				FailOnThresholdBreach: true,
+			},
+			buildCommand:     mock.CommandBuilderWithError(&mock.ExitCodeError{Code: invalidConfigExitCode}, nil, nil, nil),
+			verifyFileExists: verifyFileExists,
+		}
+
+		err := p.RunPerfTests()
+		assert.ErrorIs(t, err, ErrInvalidConfig)
+		assert.ErrorContains(t, err, "failed to compile or initialize")
+	})
+
+	t.Run("Script exception", func(t *testing.T) {
+		t.Parallel()
+
+		p := &pluginType{
+			config: config{
+				ScriptPath:            "./test/script.js",
+				FailOnThresholdBreach: true,
+			},
+			buildCommand:     mock.CommandBuilderWithError(&mock.ExitCodeError{Code: scriptExceptionExitCode}, nil, nil, nil),
+			verifyFileExists: verifyFileExists,
+		}
+
+		err := p.RunPerfTests()
+		assert.NotErrorIs(t, err, ErrInvalidConfig)
+		assert.ErrorContains(t, err, "script ./test/script.js threw an exception")
 	})
 
 	t.Run("Other exec error", func(t *testing.T) {
@@ -447,7 +624,7 @@ func TestReadLinesFromPipe(t *testing.T) {
 		wg := sync.WaitGroup{}
 		wg.Add(1)
 
-		go readLinesFromPipe(reader, &wg)
+		go readLinesFromPipe(reader, &wg, nil)
 
 		wg.Wait()
 
@@ -0,0 +1,193 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package plugin
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-vela/vela-k6/models"
+)
+
+// builtinProfiles are the load profiles available without configuration.
+// Profiles of the same name in the 'profiles' parameter replace them.
+var builtinProfiles = map[string]models.Profile{
+	"smoke": {
+		VUs:      1,
+		Duration: "1m",
+		Thresholds: map[string][]models.Threshold{
+			"http_req_failed": {{Threshold: "rate<0.01"}},
+		},
+	},
+	"load": {
+		Stages: []models.Stage{{Duration: "5m", Target: 100}, {Duration: "30m", Target: 100}, {Duration: "5m", Target: 0}},
+		Thresholds: map[string][]models.Threshold{
+			"http_req_duration": {{Threshold: "p(95)<500"}},
+			"http_req_failed":   {{Threshold: "rate<0.01"}},
+		},
+	},
+	"stress": {
+		Stages: []models.Stage{{Duration: "10m", Target: 200}, {Duration: "30m", Target: 200}, {Duration: "5m", Target: 0}},
+		Thresholds: map[string][]models.Threshold{
+			"http_req_failed": {{Threshold: "rate<0.05"}},
+		},
+	},
+	"spike": {
+		Stages: []models.Stage{{Duration: "2m", Target: 2000}, {Duration: "1m", Target: 0}},
+		Thresholds: map[string][]models.Threshold{
+			"http_req_failed": {{Threshold: "rate<0.05"}},
+		},
+	},
+	"soak": {
+		Stages: []models.Stage{{Duration: "5m", Target: 100}, {Duration: "8h", Target: 100}, {Duration: "5m", Target: 0}},
+		Thresholds: map[string][]models.Threshold{
+			"http_req_duration": {{Threshold: "p(95)<500"}},
+			"http_req_failed":   {{Threshold: "rate<0.01"}},
+		},
+	},
+}
+
+// profilesSchema is the JSON Schema of the 'profiles' parameter.
+var profilesSchema = map[string]any{
+	"type": "object",
+	"additionalProperties": map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"vus":      map[string]any{"type": "integer", "minimum": 1.0},
+			"duration": map[string]any{"type": "string"},
+			"stages": map[string]any{
+				"type": "array",
+				"items": map[string]any{
+					"type":     "object",
+					"required": []any{"duration", "target"},
+					"properties": map[string]any{
+						"duration": map[string]any{"type": "string"},
+						"target":   map[string]any{"type": "integer", "minimum": 0.0},
+					},
+					"additionalProperties": false,
+				},
+			},
+			"thresholds": thresholdsSchema,
+			"env":        map[string]any{"type": "object", "additionalProperties": map[string]any{"type": "string"}},
+			"outputs":    map[string]any{"type": "array", "items": map[string]any{"type": "string"}},
+		},
+		"additionalProperties": false,
+	},
+}
+
+// setProfiles decodes, validates and stores the 'profiles' parameter.
+func setProfiles(cfg *config, v any) error {
+	var profiles map[string]models.Profile
+	if err := json.Unmarshal(v.(json.RawMessage), &profiles); err != nil {
+		return fmt.Errorf("decode: %w", err)
+	}
+
+	for _, name := range sortedKeys(profiles) {
+		if err := validateProfile(profiles[name]); err != nil {
+			return fmt.Errorf("profile %q: %w", name, err)
+		}
+	}
+
+	cfg.Profiles = profiles
+
+	return nil
+}
+
+// validateProfile checks that the load shape of a profile is valid.
+func validateProfile(profile models.Profile) error {
+	if profile.Duration != "" && len(profile.Stages) > 0 {
+		return fmt.Errorf("'duration' and 'stages' can't be combined")
+	}
+
+	if profile.Duration != "" {
+		if _, err := time.ParseDuration(profile.Duration); err != nil {
+			return fmt.Errorf("%q is not a duration", profile.Duration)
+		}
+	}
+
+	for i, stage := range profile.Stages {
+		if _, err := time.ParseDuration(stage.Duration); err != nil {
+			return fmt.Errorf("stage %d: %q is not a duration", i, stage.Duration)
+		}
+
+		if stage.Target < 0 {
+			return fmt.Errorf("stage %d: target must not be negative", i)
+		}
+	}
+
+	if profile.VUs < 0 {
+		return fmt.Errorf("'vus' must not be negative")
+	}
+
+	return validateOutputs(profile.Outputs)
+}
+
+// applyProfile applies the load profile selected by the 'profile'
+// parameter. Its load shape is passed to k6 as flags, and its thresholds,
+// env and outputs are added to the configured ones, which take precedence.
+func (cfg *config) applyProfile() error {
+	if cfg.Profile == "" {
+		return nil
+	}
+
+	profile, ok := cfg.Profiles[cfg.Profile]
+	if !ok {
+		profile, ok = builtinProfiles[cfg.Profile]
+	}
+
+	if !ok {
+		available := maps.Clone(builtinProfiles)
+		maps.Copy(available, cfg.Profiles)
+
+		return fmt.Errorf("unknown profile %q, must be one of: %s", cfg.Profile, strings.Join(sortedKeys(available), ", "))
+	}
+
+	cfg.VUs = profile.VUs
+	cfg.Duration = profile.Duration
+	cfg.Stages = profile.Stages
+	cfg.Thresholds = mergeProfileMap(profile.Thresholds, cfg.Thresholds)
+	cfg.Env = mergeProfileMap(profile.Env, cfg.Env)
+	cfg.Outputs = append(append([]string{}, profile.Outputs...), cfg.Outputs...)
+
+	if len(cfg.Outputs) == 0 {
+		cfg.Outputs = nil
+	}
+
+	return nil
+}
+
+// mergeProfileMap returns the values of the profile overridden by the
+// configured values, or nil if both are empty.
+func mergeProfileMap[V any](profile, configured map[string]V) map[string]V {
+	if len(profile) == 0 {
+		return configured
+	}
+
+	merged := maps.Clone(profile)
+	maps.Copy(merged, configured)
+
+	return merged
+}
+
+// loadArgs returns the k6 run flags of the load shape of the profile.
+func (p *pluginType) loadArgs() []string {
+	var args []string
+
+	if p.config.VUs > 0 {
+		args = append(args, "--vus", strconv.Itoa(p.config.VUs))
+	}
+
+	if p.config.Duration != "" {
+		args = append(args, "--duration", p.config.Duration)
+	}
+
+	for _, stage := range p.config.Stages {
+		args = append(args, "--stage", fmt.Sprintf("%s:%d", stage.Duration, stage.Target))
+	}
+
+	return args
+}
@@ -0,0 +1,89 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package plugin
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/go-vela/vela-k6/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuiltinProfiles(t *testing.T) {
+	for _, name := range []string{"smoke", "load", "stress", "spike", "soak"} {
+		profile, ok := builtinProfiles[name]
+		require.True(t, ok, name)
+		assert.NoError(t, validateProfile(profile), name)
+		assert.NotEmpty(t, profile.Thresholds, name)
+	}
+}
+
+func TestApplyProfile(t *testing.T) {
+	t.Run("None", func(t *testing.T) {
+		cfg := config{Env: map[string]string{"A": "1"}}
+		require.NoError(t, cfg.applyProfile())
+		assert.Equal(t, config{Env: map[string]string{"A": "1"}}, cfg)
+	})
+	t.Run("Builtin", func(t *testing.T) {
+		cfg := config{
+			Profile:    "smoke",
+			Thresholds: map[string][]models.Threshold{"http_req_failed": {{Threshold: "rate<0.001"}}},
+		}
+		require.NoError(t, cfg.applyProfile())
+		assert.Equal(t, 1, cfg.VUs)
+		assert.Equal(t, "1m", cfg.Duration)
+		assert.Equal(t, []models.Threshold{{Threshold: "rate<0.001"}}, cfg.Thresholds["http_req_failed"])
+		assert.Nil(t, cfg.Outputs)
+	})
+	t.Run("Custom Replaces Builtin", func(t *testing.T) {
+		cfg := config{
+			Profile: "load",
+			Profiles: map[string]models.Profile{"load": {
+				Stages:  []models.Stage{{Duration: "1m", Target: 10}},
+				Env:     map[string]string{"BASE_URL": "https://staging.example.com", "VUS": "10"},
+				Outputs: []string{"csv=load.csv"},
+			}},
+			Env:     map[string]string{"VUS": "20"},
+			Outputs: []string{"json=extra.json"},
+		}
+		require.NoError(t, cfg.applyProfile())
+		assert.Equal(t, []models.Stage{{Duration: "1m", Target: 10}}, cfg.Stages)
+		assert.Empty(t, cfg.Thresholds)
+		assert.Equal(t, map[string]string{"BASE_URL": "https://staging.example.com", "VUS": "20"}, cfg.Env)
+		assert.Equal(t, []string{"csv=load.csv", "json=extra.json"}, cfg.Outputs)
+	})
+	t.Run("Unknown", func(t *testing.T) {
+		cfg := config{Profile: "nightly", Profiles: map[string]models.Profile{"canary": {VUs: 1}}}
+		assert.EqualError(t, cfg.applyProfile(), `unknown profile "nightly", must be one of: canary, load, smoke, soak, spike, stress`)
+	})
+}
+
+func TestSetProfiles(t *testing.T) {
+	cfg := &config{}
+	require.NoError(t, setProfiles(cfg, json.RawMessage(`{"canary": {"vus": 2, "duration": "30s", "env": {"A": "b"}}}`)))
+	assert.Equal(t, models.Profile{VUs: 2, Duration: "30s", Env: map[string]string{"A": "b"}}, cfg.Profiles["canary"])
+
+	for raw, expected := range map[string]string{
+		`{"a": {"duration": "1m", "stages": [{"duration": "1m", "target": 1}]}}`: "'duration' and 'stages' can't be combined",
+		`{"a": {"duration": "forever"}}`:                                         `"forever" is not a duration`,
+		`{"a": {"stages": [{"duration": "1m", "target": -1}]}}`:                  "target must not be negative",
+		`{"a": {"outputs": ["JSON file"]}}`:                                      "entry 0 is not a k6 output",
+		`{"a": {"vus": "ten"}}`:                                                  "decode",
+	} {
+		assert.ErrorContains(t, setProfiles(cfg, json.RawMessage(raw)), expected, raw)
+	}
+}
+
+func TestLoadArgs(t *testing.T) {
+	p := &pluginType{config: config{VUs: 5, Duration: "1m"}}
+	assert.Equal(t, []string{"--vus", "5", "--duration", "1m"}, p.loadArgs())
+
+	p.config = config{Stages: []models.Stage{{Duration: "30s", Target: 10}, {Duration: "1m", Target: 0}}}
+	assert.Equal(t, []string{"--stage", "30s:10", "--stage", "1m:0"}, p.loadArgs())
+
+	p.config.ScriptPath = "./test/script.js"
+	p.config.OutputPath = "./output.json"
+	assert.Equal(t, []string{"run", "-q", "--log-format=json", "--address=localhost:6565", "--stage", "30s:10", "--stage", "1m:0", "--out", "json=./output.json", "./test/script.js"}, p.k6RunArgs())
+}
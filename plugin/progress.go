@@ -0,0 +1,140 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package plugin
+
+import (
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/go-vela/vela-k6/k6api"
+	"github.com/go-vela/vela-k6/stats"
+)
+
+// minProgressInterval is the shortest accepted 'progress_interval'.
+const minProgressInterval = time.Second
+
+// validateProgressInterval checks that 'progress_interval' is not so short
+// that the status lines flood the step log.
+func validateProgressInterval(v any) error {
+	if d := v.(time.Duration); d < minProgressInterval {
+		return fmt.Errorf("%s must be at least %s", d, minProgressInterval)
+	}
+
+	return nil
+}
+
+// progressReporter logs a status line of the running test every
+// 'progress_interval', computed from the k6 metrics stream. Rates and
+// the p(95) are computed over the samples of the last interval.
+type progressReporter struct {
+	mu         sync.Mutex
+	start      time.Time
+	state      string
+	vus        float64
+	iterations int
+	requests   int
+	failed     int
+	durations  *stats.Histogram
+	stop       chan struct{}
+	wg         sync.WaitGroup
+}
+
+// newProgressReporter returns a reporter for a run started at start.
+func newProgressReporter(start time.Time) *progressReporter {
+	return &progressReporter{start: start, durations: stats.NewHistogram(), stop: make(chan struct{})}
+}
+
+// add records a sample of the metrics stream.
+func (r *progressReporter) add(point metricPoint) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	switch point.Metric {
+	case "vus":
+		r.vus = point.Value
+	case "iterations":
+		r.iterations++
+	case "http_reqs":
+		r.requests++
+	case "http_req_failed":
+		if point.Value != 0 {
+			r.failed++
+		}
+	case "http_req_duration":
+		r.durations.Add(point.Value)
+	}
+}
+
+// setStatus records the status of the test read from the k6 REST API,
+// which takes precedence over the VUs of the metrics stream.
+func (r *progressReporter) setStatus(status k6api.Status) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.state = status.State()
+	r.vus = float64(status.VUs)
+}
+
+// Start logs a status line every interval until Stop is called.
+func (r *progressReporter) Start(interval time.Duration) {
+	r.wg.Add(1)
+
+	go func() {
+		defer r.wg.Done()
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		last := r.start
+
+		for {
+			select {
+			case <-r.stop:
+				return
+			case now := <-ticker.C:
+				log.Println(r.status(now, now.Sub(last)))
+				last = now
+			}
+		}
+	}()
+}
+
+// Stop stops logging status lines.
+func (r *progressReporter) Stop() {
+	close(r.stop)
+	r.wg.Wait()
+}
+
+// status returns the status line at now for the samples received during
+// the last interval, and resets the interval counters.
+func (r *progressReporter) status(now time.Time, interval time.Duration) string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	elapsed := now.Sub(r.start).Round(time.Second)
+	rps := float64(r.requests) / interval.Seconds()
+
+	errorRate := 0.0
+	if r.requests > 0 {
+		errorRate = float64(r.failed) / float64(r.requests) * 100
+	}
+
+	p95 := "-"
+	if r.durations.Count > 0 {
+		p95 = fmt.Sprintf("%.0fms", r.durations.Percentile(95))
+	}
+
+	prefix := "Progress"
+	if r.state != "" {
+		prefix += " (" + r.state + ")"
+	}
+
+	line := fmt.Sprintf("%s: %s elapsed, %.0f VUs, %d iterations, %.1f req/s, %.2f%% errors, p(95) %s",
+		prefix, elapsed, r.vus, r.iterations, rps, errorRate, p95)
+
+	r.requests, r.failed, r.durations = 0, 0, stats.NewHistogram()
+
+	return line
+}
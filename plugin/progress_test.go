@@ -0,0 +1,44 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package plugin
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateProgressInterval(t *testing.T) {
+	assert.NoError(t, validateProgressInterval(30*time.Second))
+	assert.EqualError(t, validateProgressInterval(500*time.Millisecond), "500ms must be at least 1s")
+}
+
+func TestProgressStatus(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	r := newProgressReporter(start)
+
+	for _, point := range []metricPoint{
+		{Metric: "vus", Value: 10},
+		{Metric: "vus", Value: 20},
+		{Metric: "iterations", Value: 1},
+		{Metric: "iterations", Value: 1},
+		{Metric: "http_reqs", Value: 1},
+		{Metric: "http_reqs", Value: 1},
+		{Metric: "http_reqs", Value: 1},
+		{Metric: "http_reqs", Value: 1},
+		{Metric: "http_req_failed", Value: 1},
+		{Metric: "http_req_failed", Value: 0},
+		{Metric: "http_req_duration", Value: 100},
+		{Metric: "http_req_duration", Value: 200},
+	} {
+		r.add(point)
+	}
+
+	assert.Equal(t, "Progress: 1m0s elapsed, 20 VUs, 2 iterations, 0.4 req/s, 25.00% errors, p(95) 195ms",
+		r.status(start.Add(time.Minute), 10*time.Second))
+
+	r.add(metricPoint{Metric: "iterations", Value: 1})
+	assert.Equal(t, "Progress: 1m10s elapsed, 20 VUs, 3 iterations, 0.0 req/s, 0.00% errors, p(95) -",
+		r.status(start.Add(70*time.Second), 10*time.Second))
+}
@@ -0,0 +1,186 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package plugin
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// secretMask replaces secret values in the plugin and k6 output.
+const secretMask = "***"
+
+// minSecretLength is the length under which the values of environment
+// variables that look like secrets are not masked, so values such as
+// "true" don't mask every occurrence of the word.
+const minSecretLength = 6
+
+// defaultRedactPatterns mask the credentials of Authorization headers and
+// bearer tokens, such as those printed by k6 with --http-debug.
+var defaultRedactPatterns = []string{
+	`(?i)\bauthorization["']?\s*[:=]\s*["']?(?:(?:bearer|basic|token)\s+)?([^\s"',;]+)`,
+	`(?i)\bbearer\s+([a-z0-9\-._~+/]+=*)`,
+}
+
+// secretNamePattern matches the names of environment variables that hold
+// secrets, such as SLACK_WEBHOOK or API_TOKEN, but not MAX_TOKENS.
+var secretNamePattern = regexp.MustCompile(`(?i)(^|_)(secret|token|password|passwd|pass|api_?key|private_?key|credentials?|webhook)(_|$)`)
+
+// numberPattern matches numbers, which are not masked even when they are
+// the value of a variable that looks like a secret.
+var numberPattern = regexp.MustCompile(`^[0-9.]+$`)
+
+// redactor masks secrets in the lines and reports the plugin prints. A
+// nil redactor returns its input unchanged.
+type redactor struct {
+	// secrets are the values masked wherever they occur, longest first
+	// so overlapping values are fully masked.
+	secrets []string
+	// patterns mask their capture groups, or their whole match if they
+	// have none.
+	patterns []*regexp.Regexp
+}
+
+// validateRedactPatterns checks that every entry of 'redact_patterns' is
+// a regular expression.
+func validateRedactPatterns(v any) error {
+	for _, pattern := range v.([]string) {
+		if _, err := regexp.Compile(pattern); err != nil {
+			return fmt.Errorf("%q is not a regular expression: %w", pattern, err)
+		}
+	}
+
+	return nil
+}
+
+// newRedactor returns the redactor of the configuration. It masks the
+// secret values of secretValues, the default patterns and those of
+// 'redact_patterns'.
+func (p *pluginType) newRedactor() *redactor {
+	r := &redactor{secrets: p.secretValues()}
+
+	for _, pattern := range append(slices.Clone(defaultRedactPatterns), p.config.RedactPatterns...) {
+		r.patterns = append(r.patterns, regexp.MustCompile(pattern))
+	}
+
+	return r
+}
+
+// secretValues returns the values that must not be printed, longest
+// first: the webhook URLs, the values of the environment variables and
+// `env` entries named in 'secret_env', and the values of those whose name
+// looks like a secret, such as API_TOKEN. The PARAMETER_ variables are
+// left out, as the secrets among the parameters are masked on their own.
+func (p *pluginType) secretValues() []string {
+	var secrets []string
+
+	if p.config.Notify != nil {
+		for _, webhook := range p.config.Notify.Webhooks {
+			if webhook.URL != "" {
+				secrets = append(secrets, webhook.URL)
+			}
+		}
+	}
+
+	for _, name := range p.config.SecretEnv {
+		for _, value := range []string{p.config.Env[name], os.Getenv(name), os.Getenv(strings.ToUpper(name))} {
+			if value != "" {
+				secrets = append(secrets, value)
+			}
+		}
+	}
+
+	for _, key := range sortedKeys(p.config.Env) {
+		if looksSecret(key, p.config.Env[key]) {
+			secrets = append(secrets, p.config.Env[key])
+		}
+	}
+
+	for _, entry := range os.Environ() {
+		name, value, _ := strings.Cut(entry, "=")
+		if !strings.HasPrefix(name, "PARAMETER_") && looksSecret(name, value) {
+			secrets = append(secrets, value)
+		}
+	}
+
+	slices.Sort(secrets)
+	secrets = slices.Compact(secrets)
+
+	sort.SliceStable(secrets, func(i, j int) bool {
+		return len(secrets[i]) > len(secrets[j])
+	})
+
+	return secrets
+}
+
+// looksSecret returns true if the variable name looks like it holds a
+// secret, and its value is long enough and not a number.
+func looksSecret(name, value string) bool {
+	return secretNamePattern.MatchString(name) && len(value) >= minSecretLength && !numberPattern.MatchString(value)
+}
+
+// redact returns s with the secrets and the matches of the patterns
+// masked.
+func (r *redactor) redact(s string) string {
+	if r == nil {
+		return s
+	}
+
+	s = maskSecrets(s, r.secrets)
+
+	for _, pattern := range r.patterns {
+		s = maskPattern(s, pattern)
+	}
+
+	return s
+}
+
+// maskSecrets replaces every occurrence of each secret in s with
+// secretMask.
+func maskSecrets(s string, secrets []string) string {
+	for _, secret := range secrets {
+		s = strings.ReplaceAll(s, secret, secretMask)
+	}
+
+	return s
+}
+
+// maskPattern replaces the capture groups of every match of pattern in s
+// with secretMask, or the whole match if pattern has no group.
+func maskPattern(s string, pattern *regexp.Regexp) string {
+	matches := pattern.FindAllStringSubmatchIndex(s, -1)
+	if matches == nil {
+		return s
+	}
+
+	var b strings.Builder
+
+	last := 0
+
+	for _, match := range matches {
+		spans := match[2:]
+		if pattern.NumSubexp() == 0 {
+			spans = match[:2]
+		}
+
+		for i := 0; i < len(spans); i += 2 {
+			start, end := spans[i], spans[i+1]
+			if start < last || start == end {
+				continue
+			}
+
+			b.WriteString(s[last:start])
+			b.WriteString(secretMask)
+
+			last = end
+		}
+	}
+
+	b.WriteString(s[last:])
+
+	return b.String()
+}
@@ -0,0 +1,108 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package plugin
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/go-vela/vela-k6/merge"
+)
+
+// projektorTrendStats are the trend stats Projektor requires in the
+// summary export. They are added to the trend stats passed to k6 when
+// 'projektor_compat_mode' is enabled.
+var projektorTrendStats = []string{"p(95)", "p(90)", "avg", "min", "max", "med"}
+
+// summaryTimeUnits are the accepted values of 'summary_time_unit'.
+var summaryTimeUnits = []string{"s", "ms", "us"}
+
+// trendStatPattern matches a k6 trend stat, such as "avg" or "p(99.9)".
+var trendStatPattern = regexp.MustCompile(`^(avg|min|med|max|count|p\(\d+(\.\d+)?\))$`)
+
+// validateTrendStats checks that every entry of 'summary_trend_stats' is
+// a k6 trend stat.
+func validateTrendStats(v any) error {
+	var errs []error
+
+	for _, stat := range v.([]string) {
+		if !trendStatPattern.MatchString(stat) {
+			errs = append(errs, fmt.Errorf("%q is not a trend stat", stat))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// checkNoSummary rejects 'no_summary' when the plugin needs the summary
+// export, as k6 doesn't export the summary when it is disabled.
+func (p *pluginType) checkNoSummary() error {
+	if !p.config.NoSummary || !p.needsSummary() {
+		return nil
+	}
+
+	return errors.New("can't be combined with 'projektor_compat_mode', 'notify', 'gates' or 'browser', which need the end-of-test summary")
+}
+
+// trendStats returns the trend stats passed to k6 with
+// --summary-trend-stats, or nil to keep those of the script. In Projektor
+// compat mode, the stats Projektor requires are added to the configured
+// stats, or to those of the inspected script when none are configured.
+// In browser mode, the stat Web Vitals are assessed at is added as well,
+// to the default stats of k6 if no stats are set.
+func (p *pluginType) trendStats() []string {
+	var required []string
+
+	if p.config.ProjektorCompatMode && p.config.OutputPath != "" {
+		required = append(required, projektorTrendStats...)
+	}
+
+	if p.config.Browser {
+		required = append(required, webVitalStat)
+	}
+
+	if len(required) == 0 {
+		return p.config.SummaryTrendStats
+	}
+
+	stats := p.config.SummaryTrendStats
+	if len(stats) == 0 && p.scriptOptions != nil {
+		stats = p.scriptOptions.Options.SummaryTrendStats
+	}
+
+	if len(stats) == 0 && p.config.Browser {
+		stats = merge.DefaultTrendStats
+	}
+
+	stats = slices.Clone(stats)
+	for _, stat := range required {
+		if !slices.Contains(stats, stat) {
+			stats = append(stats, stat)
+		}
+	}
+
+	return stats
+}
+
+// summaryArgs returns the k6 run flags controlling the end-of-test
+// summary.
+func (p *pluginType) summaryArgs() []string {
+	var args []string
+
+	if p.config.NoSummary {
+		args = append(args, "--no-summary")
+	}
+
+	if stats := p.trendStats(); len(stats) > 0 {
+		args = append(args, fmt.Sprintf("--summary-trend-stats=%s", strings.Join(stats, ",")))
+	}
+
+	if p.config.SummaryTimeUnit != "" {
+		args = append(args, fmt.Sprintf("--summary-time-unit=%s", p.config.SummaryTimeUnit))
+	}
+
+	return args
+}
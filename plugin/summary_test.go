@@ -0,0 +1,75 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package plugin
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/go-vela/vela-k6/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateTrendStats(t *testing.T) {
+	assert.NoError(t, validateTrendStats([]string{"avg", "min", "med", "max", "count", "p(95)", "p(99.9)"}))
+
+	err := validateTrendStats([]string{"p95", "mean"})
+	assert.ErrorContains(t, err, `"p95" is not a trend stat`)
+	assert.ErrorContains(t, err, `"mean" is not a trend stat`)
+}
+
+func TestSummaryArgs(t *testing.T) {
+	script := &models.InspectResult{Options: models.ScriptOptions{SummaryTrendStats: []string{"avg", "p(99)"}}}
+
+	t.Run("None", func(t *testing.T) {
+		p := &pluginType{scriptOptions: script}
+		assert.Empty(t, p.summaryArgs())
+	})
+	t.Run("Configured", func(t *testing.T) {
+		p := &pluginType{config: config{SummaryTrendStats: []string{"avg", "p(99.9)"}, SummaryTimeUnit: "ms", NoSummary: true}}
+		assert.Equal(t, []string{"--no-summary", "--summary-trend-stats=avg,p(99.9)", "--summary-time-unit=ms"}, p.summaryArgs())
+	})
+	t.Run("Projektor", func(t *testing.T) {
+		p := &pluginType{config: config{ProjektorCompatMode: true, OutputPath: "output.json"}}
+		assert.Equal(t, []string{"--summary-trend-stats=p(95),p(90),avg,min,max,med"}, p.summaryArgs())
+	})
+	t.Run("Projektor Script Stats", func(t *testing.T) {
+		p := &pluginType{config: config{ProjektorCompatMode: true, OutputPath: "output.json"}, scriptOptions: script}
+		assert.Equal(t, []string{"--summary-trend-stats=avg,p(99),p(95),p(90),min,max,med"}, p.summaryArgs())
+	})
+	t.Run("Projektor Configured Stats", func(t *testing.T) {
+		p := &pluginType{config: config{ProjektorCompatMode: true, OutputPath: "output.json", SummaryTrendStats: []string{"count"}}, scriptOptions: script}
+		assert.Equal(t, []string{"--summary-trend-stats=count,p(95),p(90),avg,min,max,med"}, p.summaryArgs())
+	})
+	t.Run("Browser", func(t *testing.T) {
+		p := &pluginType{config: config{Browser: true}}
+		assert.Equal(t, []string{"--summary-trend-stats=avg,min,med,max,p(90),p(95),p(75)"}, p.summaryArgs())
+	})
+	t.Run("Browser Script Stats", func(t *testing.T) {
+		p := &pluginType{config: config{Browser: true}, scriptOptions: script}
+		assert.Equal(t, []string{"--summary-trend-stats=avg,p(99),p(75)"}, p.summaryArgs())
+	})
+}
+
+func TestCheckNoSummary(t *testing.T) {
+	assert.NoError(t, (&pluginType{config: config{NoSummary: true}}).checkNoSummary())
+	assert.NoError(t, (&pluginType{config: config{Gates: []models.Gate{{Metric: "checks"}}}}).checkNoSummary())
+	assert.ErrorContains(t, (&pluginType{config: config{NoSummary: true, ProjektorCompatMode: true, OutputPath: "output.json"}}).checkNoSummary(), "can't be combined")
+	assert.ErrorContains(t, (&pluginType{config: config{NoSummary: true, Gates: []models.Gate{{Metric: "checks"}}}}).checkNoSummary(), "can't be combined")
+}
+
+func TestSummaryExportPath(t *testing.T) {
+	dir := t.TempDir()
+	temp := filepath.Join(dir, summaryExportFile)
+
+	// the final metrics are read from the summary export without an output file
+	assert.Equal(t, temp, (&pluginType{tempDir: dir}).summaryExportPath())
+	assert.Empty(t, (&pluginType{config: config{NoSummary: true}, tempDir: dir}).summaryExportPath())
+	assert.Empty(t, (&pluginType{config: config{OutputPath: "output.json"}, tempDir: dir}).summaryExportPath())
+	assert.Equal(t, temp, (&pluginType{config: config{OutputPath: "output.json", Gates: []models.Gate{{Metric: "checks"}}}, tempDir: dir}).summaryExportPath())
+	assert.Equal(t, "output.json", (&pluginType{config: config{OutputPath: "output.json", ProjektorCompatMode: true}, tempDir: dir}).summaryExportPath())
+
+	// k6 only lingers on request
+	assert.NotContains(t, (&pluginType{config: config{ScriptPath: "./test/script.js"}, tempDir: dir}).k6RunArgs(), "--linger")
+	assert.Contains(t, (&pluginType{config: config{ScriptPath: "./test/script.js", Linger: true}, tempDir: dir}).k6RunArgs(), "--linger")
+}
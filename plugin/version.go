@@ -0,0 +1,110 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package plugin
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/go-vela/vela-k6/models"
+)
+
+var (
+	// k6VersionPattern matches the first line of `k6 version`, such as
+	// "k6 v1.0.0 (commit/41b4984b75, go1.24.2, linux/amd64)".
+	k6VersionPattern = regexp.MustCompile(`^k6 (v\S+)`)
+	// k6ExtensionPattern matches an extension line of `k6 version`, such
+	// as "  github.com/grafana/xk6-sql v0.4.0, k6/x/sql [js]".
+	k6ExtensionPattern = regexp.MustCompile(`^\s+(\S+) (\S+), (\S+) \[(\w+)\]$`)
+)
+
+// Quiet returns true if the 'quiet' parameter is set, in which case the
+// startup line with the version information is not logged.
+func (p *pluginType) Quiet() bool {
+	return p.config.Quiet
+}
+
+// K6BinaryFromEnv sets the k6 binary from the 'k6_binary_path'
+// parameter, read from the environment or the plan file like
+// ConfigFromEnv reads it, without requiring the other parameters. It
+// lets K6Version report the binary the step runs.
+func (p *pluginType) K6BinaryFromEnv() error {
+	ws, err := newWorkspace()
+	if err != nil {
+		return err
+	}
+
+	lookup, err := configLookup(ws)
+	if err != nil {
+		return fmt.Errorf("%w: 'config_path': %w", ErrInvalidConfig, err)
+	}
+
+	if err := parseParameter(&p.config, ws, lookup, parameterByName("k6_binary_path")); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidConfig, err)
+	}
+
+	return nil
+}
+
+// K6Version runs `k6 version` with the configured k6 binary and returns
+// the version of k6 and the extensions it was built with.
+func (p *pluginType) K6Version() (*models.K6Version, error) {
+	cmd := p.buildCommand(p.k6Binary(), "version")
+
+	stdout, stderr, err := startCommand(cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	wg := sync.WaitGroup{}
+	wg.Add(1)
+
+	go readLinesFromPipe(stderr, &wg, p.newRedactor())
+
+	output, readErr := io.ReadAll(stdout)
+
+	wg.Wait()
+
+	if err := cmd.Wait(); err != nil {
+		return nil, fmt.Errorf("k6 version: %w", err)
+	}
+
+	if readErr != nil {
+		return nil, fmt.Errorf("read k6 version output: %w", readErr)
+	}
+
+	return parseK6Version(string(output))
+}
+
+// parseK6Version parses the output of `k6 version`.
+func parseK6Version(output string) (*models.K6Version, error) {
+	scanner := bufio.NewScanner(strings.NewReader(output))
+
+	if !scanner.Scan() {
+		return nil, fmt.Errorf("k6 version printed nothing")
+	}
+
+	match := k6VersionPattern.FindStringSubmatch(scanner.Text())
+	if match == nil {
+		return nil, fmt.Errorf("unexpected k6 version output %q", scanner.Text())
+	}
+
+	version := &models.K6Version{Version: match[1]}
+
+	for scanner.Scan() {
+		if match := k6ExtensionPattern.FindStringSubmatch(scanner.Text()); match != nil {
+			version.Extensions = append(version.Extensions, models.K6Extension{
+				Module:  match[1],
+				Version: match[2],
+				Name:    match[3],
+				Type:    match[4],
+			})
+		}
+	}
+
+	return version, nil
+}
@@ -0,0 +1,114 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package plugin
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/go-vela/vela-k6/models"
+	"github.com/go-vela/vela-k6/plugin/mock"
+)
+
+const k6VersionOutput = `k6 v1.0.0 (commit/41b4984b75, go1.24.2, linux/amd64)
+Extensions:
+  github.com/grafana/xk6-sql v0.4.0, k6/x/sql [js]
+  github.com/grafana/xk6-output-influxdb v0.4.1, xk6-influxdb [output]
+`
+
+func TestK6Version(t *testing.T) {
+	t.Run("With extensions", func(t *testing.T) {
+		t.Parallel()
+
+		p := &pluginType{buildCommand: mock.CommandBuilderWithOutput(k6VersionOutput, nil)}
+
+		version, err := p.K6Version()
+		require.NoError(t, err)
+		assert.Equal(t, &models.K6Version{
+			Version: "v1.0.0",
+			Extensions: []models.K6Extension{
+				{Module: "github.com/grafana/xk6-sql", Version: "v0.4.0", Name: "k6/x/sql", Type: "js"},
+				{Module: "github.com/grafana/xk6-output-influxdb", Version: "v0.4.1", Name: "xk6-influxdb", Type: "output"},
+			},
+		}, version)
+	})
+	t.Run("Without extensions", func(t *testing.T) {
+		t.Parallel()
+
+		p := &pluginType{buildCommand: mock.CommandBuilderWithOutput("k6 v0.45.0 (2023-06-19T08:40:14+0000/v0.45.0-0-gc3b4587b, go1.20.5, linux/amd64)\n", nil)}
+
+		version, err := p.K6Version()
+		require.NoError(t, err)
+		assert.Equal(t, &models.K6Version{Version: "v0.45.0"}, version)
+	})
+	t.Run("Unexpected output", func(t *testing.T) {
+		t.Parallel()
+
+		p := &pluginType{buildCommand: mock.CommandBuilderWithOutput("Usage: k6 [command]\n", nil)}
+
+		_, err := p.K6Version()
+		assert.ErrorContains(t, err, "unexpected k6 version output")
+	})
+	t.Run("Command error", func(t *testing.T) {
+		t.Parallel()
+
+		p := &pluginType{buildCommand: mock.CommandBuilderWithError(errors.New("exit status 1"), nil, nil, nil)}
+
+		_, err := p.K6Version()
+		assert.ErrorContains(t, err, "k6 version: exit status 1")
+	})
+}
+
+func TestK6BinaryFromEnv(t *testing.T) {
+	dir, err := filepath.EvalSymlinks(t.TempDir())
+	require.NoError(t, err)
+
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "bin"), 0750))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "bin", "k6"), []byte("#!/bin/sh\n"), 0700))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "plan.yml"), []byte("k6_binary_path: ./bin/k6\n"), 0600))
+
+	t.Setenv("VELA_WORKSPACE", dir)
+	t.Chdir(dir)
+
+	t.Run("Env", func(t *testing.T) {
+		t.Setenv("PARAMETER_K6_BINARY_PATH", "./bin/k6")
+
+		var binary string
+
+		p := &pluginType{buildCommand: func(name string, args ...string) models.ShellCommand {
+			binary = name
+			return mock.CommandBuilderWithOutput(k6VersionOutput, nil)(name, args...)
+		}}
+
+		require.NoError(t, p.K6BinaryFromEnv())
+
+		_, err := p.K6Version()
+		require.NoError(t, err)
+		assert.Equal(t, filepath.Join(dir, "bin", "k6"), binary)
+	})
+	t.Run("Plan", func(t *testing.T) {
+		t.Setenv("PARAMETER_CONFIG_PATH", "plan.yml")
+
+		p := &pluginType{}
+		require.NoError(t, p.K6BinaryFromEnv())
+		assert.Equal(t, filepath.Join(dir, "bin", "k6"), p.k6Binary())
+	})
+	t.Run("Not Set", func(t *testing.T) {
+		p := &pluginType{}
+		require.NoError(t, p.K6BinaryFromEnv())
+		assert.Equal(t, "k6", p.k6Binary())
+	})
+	t.Run("Invalid", func(t *testing.T) {
+		t.Setenv("PARAMETER_K6_BINARY_PATH", "./plan.yml")
+
+		p := &pluginType{}
+		err := p.K6BinaryFromEnv()
+		assert.ErrorIs(t, err, ErrInvalidConfig)
+		assert.ErrorContains(t, err, "'k6_binary_path': path is not executable")
+	})
+}
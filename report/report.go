@@ -0,0 +1,172 @@
+// SPDX-License-Identifier: Apache-2.0
+
+// Package report renders k6 summary exports for people and compares them
+// against a baseline.
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/go-vela/vela-k6/models"
+)
+
+// Formats of a rendered summary.
+const (
+	FormatText     = "text"
+	FormatMarkdown = "markdown"
+	FormatJSON     = "json"
+)
+
+// Formats are the accepted formats of Write.
+var Formats = []string{FormatText, FormatMarkdown, FormatJSON}
+
+// DefaultTolerance is the percentage by which a stat may exceed the
+// baseline before it is reported as a regression.
+const DefaultTolerance = 10.0
+
+// regressionStats are the trend stats compared against the baseline
+// summary. Higher values are considered worse.
+var regressionStats = []string{"avg", "p(95)"}
+
+// Write renders the metrics of summary and the result of their thresholds
+// to w, in the given format.
+func Write(w io.Writer, summary *models.Summary, format string) error {
+	switch format {
+	case FormatText:
+		return writeText(w, summary)
+	case FormatMarkdown:
+		return writeMarkdown(w, summary)
+	case FormatJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+
+		return enc.Encode(summary)
+	default:
+		return fmt.Errorf("unknown format %q, must be one of: %s", format, strings.Join(Formats, ", "))
+	}
+}
+
+// writeText renders one line per metric with its stats, followed by the
+// result of its thresholds.
+func writeText(w io.Writer, summary *models.Summary) error {
+	var b strings.Builder
+
+	for _, name := range slices.Sorted(maps.Keys(summary.Metrics)) {
+		metric := summary.Metrics[name]
+
+		fmt.Fprintf(&b, "%s: %s\n", name, formatStats(metric, "="))
+
+		for _, expression := range slices.Sorted(maps.Keys(metric.Thresholds)) {
+			fmt.Fprintf(&b, "  %s %s\n", thresholdMark(metric.Thresholds[expression]), expression)
+		}
+	}
+
+	fmt.Fprintln(&b, thresholdsLine(summary))
+
+	_, err := io.WriteString(w, b.String())
+
+	return err
+}
+
+// writeMarkdown renders a table of the metrics and their thresholds.
+func writeMarkdown(w io.Writer, summary *models.Summary) error {
+	var b strings.Builder
+
+	b.WriteString("| Metric | Stats | Thresholds |\n| --- | --- | --- |\n")
+
+	for _, name := range slices.Sorted(maps.Keys(summary.Metrics)) {
+		metric := summary.Metrics[name]
+
+		var thresholds []string
+		for _, expression := range slices.Sorted(maps.Keys(metric.Thresholds)) {
+			thresholds = append(thresholds, fmt.Sprintf("%s `%s`", thresholdMark(metric.Thresholds[expression]), expression))
+		}
+
+		fmt.Fprintf(&b, "| `%s` | %s | %s |\n", name, formatStats(metric, ": "), strings.Join(thresholds, "<br>"))
+	}
+
+	fmt.Fprintf(&b, "\n%s\n", thresholdsLine(summary))
+
+	_, err := io.WriteString(w, b.String())
+
+	return err
+}
+
+// formatStats returns the stats of the metric sorted by name, with sep
+// between each name and value.
+func formatStats(metric models.Metric, sep string) string {
+	stats := make([]string, 0, len(metric.Values))
+	for _, stat := range slices.Sorted(maps.Keys(metric.Values)) {
+		stats = append(stats, fmt.Sprintf("%s%s%s", stat, sep, formatValue(metric.Values[stat])))
+	}
+
+	return strings.Join(stats, ", ")
+}
+
+// formatValue formats a stat with up to 2 decimals.
+func formatValue(v float64) string {
+	s := fmt.Sprintf("%.2f", v)
+	s = strings.TrimRight(s, "0")
+
+	return strings.TrimSuffix(s, ".")
+}
+
+// thresholdMark returns the mark of a passed or failed threshold.
+func thresholdMark(failed bool) string {
+	if failed {
+		return "✗"
+	}
+
+	return "✓"
+}
+
+// thresholdsLine returns the closing line counting the breached
+// thresholds.
+func thresholdsLine(summary *models.Summary) string {
+	total := 0
+	for _, metric := range summary.Metrics {
+		total += len(metric.Thresholds)
+	}
+
+	return fmt.Sprintf("%d of %d thresholds breached", len(summary.BreachedThresholds()), total)
+}
+
+// Compare returns a description of every trend stat in current that is
+// worse than the same stat in baseline by more than tolerance percent,
+// sorted alphabetically.
+func Compare(baseline, current *models.Summary, tolerance float64) []string {
+	var regressions []string
+
+	for name, metric := range current.Metrics {
+		base, ok := baseline.Metrics[name]
+		if !ok {
+			continue
+		}
+
+		for _, stat := range regressionStats {
+			cur, ok := metric.Value(stat)
+			if !ok {
+				continue
+			}
+
+			prev, ok := base.Value(stat)
+			if !ok || prev <= 0 {
+				continue
+			}
+
+			change := (cur - prev) / prev * 100
+			if change > tolerance {
+				regressions = append(regressions, fmt.Sprintf("%s %s %.2f -> %.2f (+%.1f%%)", name, stat, prev, cur, change))
+			}
+		}
+	}
+
+	slices.Sort(regressions)
+
+	return regressions
+}
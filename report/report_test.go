@@ -0,0 +1,73 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package report
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/go-vela/vela-k6/models"
+)
+
+// summary is a summary export with a passed and a breached threshold.
+var summary = &models.Summary{Metrics: map[string]models.Metric{
+	"http_req_duration": {
+		Values:     map[string]float64{"avg": 105.256, "p(95)": 250},
+		Thresholds: map[string]bool{"p(95)<200": true, "avg<500": false},
+	},
+	"http_reqs": {Values: map[string]float64{"count": 100, "rate": 10.5}},
+}}
+
+func TestWrite(t *testing.T) {
+	t.Run("Text", func(t *testing.T) {
+		var b strings.Builder
+		require.NoError(t, Write(&b, summary, FormatText))
+		assert.Equal(t, `http_req_duration: avg=105.26, p(95)=250
+  ✓ avg<500
+  ✗ p(95)<200
+http_reqs: count=100, rate=10.5
+1 of 2 thresholds breached
+`, b.String())
+	})
+
+	t.Run("Markdown", func(t *testing.T) {
+		var b strings.Builder
+		require.NoError(t, Write(&b, summary, FormatMarkdown))
+		assert.Equal(t, "| Metric | Stats | Thresholds |\n| --- | --- | --- |\n"+
+			"| `http_req_duration` | avg: 105.26, p(95): 250 | ✓ `avg<500`<br>✗ `p(95)<200` |\n"+
+			"| `http_reqs` | count: 100, rate: 10.5 |  |\n"+
+			"\n1 of 2 thresholds breached\n", b.String())
+	})
+
+	t.Run("JSON", func(t *testing.T) {
+		var b strings.Builder
+		require.NoError(t, Write(&b, summary, FormatJSON))
+
+		var decoded models.Summary
+		require.NoError(t, json.Unmarshal([]byte(b.String()), &decoded))
+		assert.Equal(t, summary, &decoded)
+	})
+
+	t.Run("Unknown Format", func(t *testing.T) {
+		assert.EqualError(t, Write(&strings.Builder{}, summary, "html"), `unknown format "html", must be one of: text, markdown, json`)
+	})
+}
+
+func TestCompare(t *testing.T) {
+	baseline := &models.Summary{Metrics: map[string]models.Metric{
+		"http_req_duration": {Values: map[string]float64{"avg": 100, "p(95)": 200}},
+		"http_reqs":         {Values: map[string]float64{"count": 10}},
+	}}
+	current := &models.Summary{Metrics: map[string]models.Metric{
+		"http_req_duration":  {Values: map[string]float64{"avg": 105, "p(95)": 250}},
+		"http_reqs":          {Values: map[string]float64{"count": 100}},
+		"iteration_duration": {Values: map[string]float64{"avg": 1000}},
+	}}
+
+	assert.Equal(t, []string{"http_req_duration p(95) 200.00 -> 250.00 (+25.0%)"}, Compare(baseline, current, 10))
+	assert.Empty(t, Compare(baseline, current, 30))
+}
@@ -0,0 +1,152 @@
+// SPDX-License-Identifier: Apache-2.0
+
+// Package stats aggregates k6 metric samples in bounded memory and
+// evaluates k6 threshold expressions on their stats. It is shared by the
+// live abort conditions and progress lines of a run and by the merge of
+// several runs, so they agree on the stats and the threshold grammar.
+package stats
+
+import (
+	"maps"
+	"math"
+	"slices"
+)
+
+// histogramGamma is the ratio between the bounds of the logarithmic
+// buckets percentiles are estimated with, which bounds their relative
+// error to 1%.
+const histogramGamma = 1.02
+
+// logHistogramGamma is the logarithm of histogramGamma.
+var logHistogramGamma = math.Log(histogramGamma)
+
+// Histogram aggregates metric samples in bounded memory: the count, sum,
+// extremes and number of non-zero values are kept exactly, and
+// percentiles are estimated from a logarithmic histogram, whose number of
+// buckets depends on the range of the values rather than their count.
+type Histogram struct {
+	Count    int
+	NonZero  int
+	Sum      float64
+	Min      float64
+	Max      float64
+	zeros    int
+	positive map[int]int
+	negative map[int]int
+}
+
+// NewHistogram returns an empty histogram.
+func NewHistogram() *Histogram {
+	return &Histogram{positive: map[int]int{}, negative: map[int]int{}}
+}
+
+// Add records a sample.
+func (h *Histogram) Add(value float64) {
+	if h.Count == 0 || value < h.Min {
+		h.Min = value
+	}
+
+	if h.Count == 0 || value > h.Max {
+		h.Max = value
+	}
+
+	h.Count++
+	h.Sum += value
+
+	switch {
+	case value > 0:
+		h.NonZero++
+		h.positive[bucketIndex(value)]++
+	case value < 0:
+		h.NonZero++
+		h.negative[bucketIndex(-value)]++
+	default:
+		h.zeros++
+	}
+}
+
+// Merge adds the samples of other.
+func (h *Histogram) Merge(other *Histogram) {
+	if other.Count == 0 {
+		return
+	}
+
+	if h.Count == 0 || other.Min < h.Min {
+		h.Min = other.Min
+	}
+
+	if h.Count == 0 || other.Max > h.Max {
+		h.Max = other.Max
+	}
+
+	h.Count += other.Count
+	h.NonZero += other.NonZero
+	h.Sum += other.Sum
+	h.zeros += other.zeros
+
+	for index, n := range other.positive {
+		h.positive[index] += n
+	}
+
+	for index, n := range other.negative {
+		h.negative[index] += n
+	}
+}
+
+// Avg returns the mean of the samples.
+func (h *Histogram) Avg() float64 {
+	return h.Sum / float64(h.Count)
+}
+
+// Percentile returns the estimated p-th percentile of the samples,
+// interpolated linearly between the closest ranks like k6 does.
+func (h *Histogram) Percentile(p float64) float64 {
+	rank := p / 100 * float64(h.Count-1)
+	lower := int(math.Floor(rank))
+	upper := int(math.Ceil(rank))
+
+	low, high := h.valueAt(lower), h.valueAt(upper)
+
+	return low + (high-low)*(rank-float64(lower))
+}
+
+// valueAt returns the estimated value of the sample at rank i, in
+// ascending order, clamped to the extremes.
+func (h *Histogram) valueAt(i int) float64 {
+	seen := 0
+
+	for _, index := range slices.Backward(slices.Sorted(maps.Keys(h.negative))) {
+		if seen += h.negative[index]; i < seen {
+			return h.clamp(-bucketValue(index))
+		}
+	}
+
+	if seen += h.zeros; i < seen {
+		return 0
+	}
+
+	for _, index := range slices.Sorted(maps.Keys(h.positive)) {
+		if seen += h.positive[index]; i < seen {
+			return h.clamp(bucketValue(index))
+		}
+	}
+
+	return h.Max
+}
+
+// clamp returns value bounded by the extremes of the samples.
+func (h *Histogram) clamp(value float64) float64 {
+	return math.Min(math.Max(value, h.Min), h.Max)
+}
+
+// bucketIndex returns the index of the histogram bucket holding the
+// positive value, in (gamma^(index-1), gamma^index].
+func bucketIndex(value float64) int {
+	return int(math.Ceil(math.Log(value) / logHistogramGamma))
+}
+
+// bucketValue returns the value representing a histogram bucket, whose
+// relative error is at most (gamma-1)/(gamma+1) for any value in it.
+func bucketValue(index int) float64 {
+	return 2 * math.Pow(histogramGamma, float64(index)) / (histogramGamma + 1)
+}
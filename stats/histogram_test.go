@@ -0,0 +1,85 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package stats
+
+import (
+	"math"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// exactPercentile returns the p-th percentile of sorted values, as k6
+// computes it from every sample.
+func exactPercentile(sorted []float64, p float64) float64 {
+	rank := p / 100 * float64(len(sorted)-1)
+	lower, upper := int(math.Floor(rank)), int(math.Ceil(rank))
+
+	return sorted[lower] + (sorted[upper]-sorted[lower])*(rank-float64(lower))
+}
+
+func TestHistogram(t *testing.T) {
+	t.Run("Percentiles", func(t *testing.T) {
+		h := NewHistogram()
+		values := make([]float64, 0, 100000)
+
+		for i := range 100000 {
+			value := float64(i%5000) + 0.5
+			h.Add(value)
+			values = append(values, value)
+		}
+
+		slices.Sort(values)
+
+		// the histogram is bounded by the range of the values, not their count
+		assert.Less(t, len(h.positive), 500)
+
+		for _, p := range []float64{1, 50, 90, 95, 99, 99.9} {
+			assert.InEpsilon(t, exactPercentile(values, p), h.Percentile(p), 0.01, p)
+		}
+
+		assert.Equal(t, 100000, h.Count)
+		assert.Equal(t, 0.5, h.Min)
+		assert.Equal(t, 4999.5, h.Max)
+		assert.Equal(t, 4999.5, h.Percentile(100))
+		assert.InDelta(t, 2500, h.Avg(), 0.001)
+	})
+	t.Run("Negative Values", func(t *testing.T) {
+		h := NewHistogram()
+		for _, value := range []float64{-10, -1, 0, 0, 1, 10} {
+			h.Add(value)
+		}
+
+		assert.Equal(t, -10.0, h.Percentile(0))
+		assert.InDelta(t, -1, h.Percentile(20), 0.01)
+		assert.Zero(t, h.Percentile(50))
+		assert.InDelta(t, 1, h.Percentile(80), 0.01)
+		assert.Equal(t, 10.0, h.Percentile(100))
+		assert.Equal(t, 4, h.NonZero)
+	})
+	t.Run("Single Value", func(t *testing.T) {
+		h := NewHistogram()
+		h.Add(5)
+
+		assert.Equal(t, 5.0, h.Percentile(95))
+	})
+	t.Run("Merge", func(t *testing.T) {
+		first, second := NewHistogram(), NewHistogram()
+		first.Add(5)
+		first.Add(0)
+		second.Add(20)
+
+		merged := NewHistogram()
+		merged.Merge(first)
+		merged.Merge(second)
+		merged.Merge(NewHistogram())
+
+		assert.Equal(t, 3, merged.Count)
+		assert.Equal(t, 2, merged.NonZero)
+		assert.Equal(t, 25.0, merged.Sum)
+		assert.Equal(t, 0.0, merged.Min)
+		assert.Equal(t, 20.0, merged.Max)
+		assert.InDelta(t, 5, merged.Percentile(50), 0.05)
+	})
+}
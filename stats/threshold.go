@@ -0,0 +1,78 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package stats
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// thresholdPattern matches a k6 threshold expression on an aggregation of
+// a metric, such as "p(95) < 200" or "rate<=0.01", capturing the stat,
+// the operator and the operand.
+var thresholdPattern = regexp.MustCompile(`^\s*(avg|min|max|med|count|rate|value|p\(\d+(?:\.\d+)?\))\s*(<=|>=|===|==|!=|<|>)\s*(-?\d+(?:\.\d+)?)\s*$`)
+
+// Threshold is a parsed k6 threshold expression: it passes when the stat
+// compares true to the value.
+type Threshold struct {
+	Stat     string
+	Operator string
+	Value    float64
+}
+
+// ParseThreshold parses a k6 threshold expression.
+func ParseThreshold(expression string) (Threshold, error) {
+	m := thresholdPattern.FindStringSubmatch(expression)
+	if m == nil {
+		return Threshold{}, fmt.Errorf("%q is not a threshold expression (e.g. \"p(95)<500\")", expression)
+	}
+
+	value, err := strconv.ParseFloat(m[3], 64)
+	if err != nil {
+		return Threshold{}, fmt.Errorf("%q: %w", expression, err)
+	}
+
+	return Threshold{Stat: m[1], Operator: m[2], Value: value}, nil
+}
+
+// Passes returns true if actual, the value of the stat, compares true to
+// the value of the threshold.
+func (t Threshold) Passes(actual float64) bool {
+	return Compare(actual, t.Operator, t.Value)
+}
+
+// Compare returns true if actual compares true to value with operator,
+// one of the operators of threshold expressions.
+func Compare(actual float64, operator string, value float64) bool {
+	switch operator {
+	case "<":
+		return actual < value
+	case "<=":
+		return actual <= value
+	case ">":
+		return actual > value
+	case ">=":
+		return actual >= value
+	case "!=":
+		return actual != value
+	default:
+		return actual == value
+	}
+}
+
+// ParsePercentile returns p for a percentile stat named "p(<p>)", or
+// false if name isn't a percentile between 0 and 100.
+func ParsePercentile(name string) (float64, bool) {
+	if !strings.HasPrefix(name, "p(") || !strings.HasSuffix(name, ")") {
+		return 0, false
+	}
+
+	p, err := strconv.ParseFloat(name[2:len(name)-1], 64)
+	if err != nil || p < 0 || p > 100 {
+		return 0, false
+	}
+
+	return p, true
+}
@@ -0,0 +1,60 @@
+// SPDX-License-Identifier: Apache-2.0
+
+package stats
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseThreshold(t *testing.T) {
+	for expression, want := range map[string]Threshold{
+		"p(95)<500":        {Stat: "p(95)", Operator: "<", Value: 500},
+		" p(99.9) <= 1.5 ": {Stat: "p(99.9)", Operator: "<=", Value: 1.5},
+		"rate===0":         {Stat: "rate", Operator: "===", Value: 0},
+		"value != -1":      {Stat: "value", Operator: "!=", Value: -1},
+	} {
+		threshold, err := ParseThreshold(expression)
+		require.NoError(t, err, expression)
+		assert.Equal(t, want, threshold, expression)
+	}
+
+	for _, expression := range []string{"p(95) less than 500", "p95<500", "avg<", "rate<0.1 && avg<1"} {
+		_, err := ParseThreshold(expression)
+		assert.ErrorContains(t, err, "is not a threshold expression", expression)
+	}
+}
+
+func TestThresholdPasses(t *testing.T) {
+	for _, tc := range []struct {
+		expression string
+		actual     float64
+		passes     bool
+	}{
+		{"avg<10", 5, true},
+		{"avg<10", 10, false},
+		{"avg<=10", 10, true},
+		{"avg>10", 10, false},
+		{"avg>=10", 10, true},
+		{"count==3", 3, true},
+		{"count===3", 4, false},
+		{"count!=3", 4, true},
+	} {
+		threshold, err := ParseThreshold(tc.expression)
+		require.NoError(t, err)
+		assert.Equal(t, tc.passes, threshold.Passes(tc.actual), tc.expression)
+	}
+}
+
+func TestParsePercentile(t *testing.T) {
+	p, ok := ParsePercentile("p(99.9)")
+	assert.True(t, ok)
+	assert.Equal(t, 99.9, p)
+
+	for _, name := range []string{"avg", "p(101)", "p(x)", "p95"} {
+		_, ok := ParsePercentile(name)
+		assert.False(t, ok, name)
+	}
+}